@@ -0,0 +1,64 @@
+package aezeedcheck
+
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+// KeyToP2wkhAddr returns the p2wkh address of the passed key, encoded for the
+// given network.
+func KeyToP2wkhAddr(key *btcec.PublicKey,
+	params *chaincfg.Params) (btcutil.Address, error) {
+
+	pubKeyHash := btcutil.Hash160(key.SerializeCompressed())
+
+	return btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+}
+
+// KeyToNp2wkhAddr returns the np2wkh address (p2wkh nested within p2sh) of the
+// passed key, encoded for the given network.
+func KeyToNp2wkhAddr(key *btcec.PublicKey,
+	params *chaincfg.Params) (btcutil.Address, error) {
+
+	pubKeyHash := btcutil.Hash160(key.SerializeCompressed())
+
+	// First, we'll generate a normal p2wkh address from the pubkey hash.
+	witAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		pubKeyHash, params,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Next we'll generate the witness program which can be used as a
+	// pkScript to pay to this generated address.
+	witnessProgram, err := txscript.PayToAddrScript(witAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Finally, we'll use the witness program itself as the pre-image to a
+	// p2sh address. In order to spend, we first use the witnessProgram as
+	// the sigScript, then present the proper <sig, pubkey> pair as the
+	// witness.
+	return btcutil.NewAddressScriptHash(
+		witnessProgram, params,
+	)
+}
+
+// KeyToP2pkhAddr returns the legacy p2pkh address of the passed key, encoded
+// for the given network. If compressed is false, then the address commits to
+// the uncompressed serialization of the key instead.
+func KeyToP2pkhAddr(key *btcec.PublicKey, compressed bool,
+	params *chaincfg.Params) (btcutil.Address, error) {
+
+	serializedKey := key.SerializeCompressed()
+	if !compressed {
+		serializedKey = key.SerializeUncompressed()
+	}
+	pubKeyHash := btcutil.Hash160(serializedKey)
+
+	return btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+}
@@ -0,0 +1,101 @@
+package aezeedcheck
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// VerifyBIP39Checksum checks that the passed words make up a BIP0039 mnemonic
+// of 12, 15, 18, 21 or 24 words with a valid checksum. Words that aren't part
+// of the word list must have been rejected by the caller already.
+func VerifyBIP39Checksum(words []string) error {
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return fmt.Errorf("invalid BIP0039 mnemonic length %v",
+			len(words))
+	}
+
+	// Each word encodes 11 bits, of which the last one bit per 3 words
+	// makes up the checksum of the entropy before it.
+	numBits := len(words) * bitsPerWord
+	data := make([]byte, (numBits+7)/8)
+	defer ZeroBytes(data)
+	var bitPos int
+	for _, word := range words {
+		index := wordIndex[word]
+		for i := bitsPerWord - 1; i >= 0; i-- {
+			if index>>uint(i)&1 == 1 {
+				data[bitPos/8] |= 0x80 >> uint(bitPos%8)
+			}
+			bitPos++
+		}
+	}
+
+	numChecksumBits := len(words) / 3
+	entropyLen := (numBits - numChecksumBits) / 8
+	checksum := sha256.Sum256(data[:entropyLen])
+	mask := byte(0xff) << uint(8-numChecksumBits)
+	if (data[entropyLen]^checksum[0])&mask != 0 {
+		return errors.New("BIP0039 checksum mismatch")
+	}
+
+	return nil
+}
+
+// LooksLikeBIP39 returns true if the passed words are far more likely to be a
+// BIP0039 mnemonic than the mnemonic of an aezeed. As both schemes share the
+// same word list, the words themselves can't tell them apart, but the
+// checksums can: a BIP0039 mnemonic has a valid BIP0039 checksum, and unless
+// it has 24 words, it has the wrong length for an aezeed, while one of 24
+// words fails the version and checksum checks of an aezeed.
+func LooksLikeBIP39(words []string) bool {
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = strings.ToLower(strings.TrimSpace(word))
+		if !IsWord(normalized[i]) {
+			return false
+		}
+	}
+
+	if VerifyBIP39Checksum(normalized) != nil {
+		return false
+	}
+
+	return len(normalized) != aezeed.NummnemonicWords ||
+		VerifyChecksum(normalized) != nil
+}
+
+// EntropyToBIP39 encodes the passed entropy as a BIP0039 mnemonic, using the
+// English word list shared with aezeed. The entropy must be between 16 and 32
+// bytes, in multiples of 4.
+//
+// NOTE: A BIP0039 mnemonic derives its root key from a PBKDF2 stretch of the
+// words themselves, rather than from the raw entropy like aezeed does, so the
+// resulting mnemonic leads to entirely different keys and addresses.
+func EntropyToBIP39(entropy []byte) ([]string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return nil, fmt.Errorf("invalid BIP0039 entropy length %v",
+			len(entropy))
+	}
+
+	// The checksum is made up of the first bit of the sha256 of the
+	// entropy for every 32 bits of entropy, and is appended to it.
+	checksum := sha256.Sum256(entropy)
+	numBits := len(entropy)*8 + len(entropy)/4
+	data := append(append([]byte(nil), entropy...), checksum[0])
+
+	words := make([]string, 0, numBits/bitsPerWord)
+	for i := 0; i < numBits; i += bitsPerWord {
+		var index int
+		for bit := i; bit < i+bitsPerWord; bit++ {
+			index <<= 1
+			index |= int(data[bit/8]>>uint(7-bit%8)) & 1
+		}
+		words = append(words, WordList[index])
+	}
+
+	return words, nil
+}
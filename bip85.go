@@ -0,0 +1,208 @@
+package aezeedcheck
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+const (
+	// BIP85AppBIP39 is the BIP0085 application that derives a child
+	// BIP0039 mnemonic.
+	BIP85AppBIP39 = "bip39"
+
+	// BIP85AppHex is the BIP0085 application that derives raw
+	// hex-encoded entropy.
+	BIP85AppHex = "hex"
+
+	// BIP85AppWIF is the BIP0085 application that derives a WIF encoded
+	// private key.
+	BIP85AppWIF = "wif"
+
+	// bip85Purpose is the purpose of all BIP0085 derivation paths.
+	bip85Purpose = 83696968
+
+	// bip85BIP39Number, bip85HexNumber and bip85WIFNumber are the
+	// application numbers of the BIP0085 applications we support.
+	bip85BIP39Number = 39
+	bip85HexNumber   = 128169
+	bip85WIFNumber   = 2
+
+	// bip85EnglishLanguage is the language code of the English BIP0039
+	// word list, which is the only one we support.
+	bip85EnglishLanguage = 0
+
+	// bip85MinHexBytes and bip85MaxHexBytes bound the number of bytes the
+	// hex application may derive.
+	bip85MinHexBytes = 16
+	bip85MaxHexBytes = 64
+)
+
+// bip85HMACKey is the HMAC-SHA512 key that the private key of a BIP0085
+// derivation path is hashed with to get its entropy.
+var bip85HMACKey = []byte("bip-entropy-from-k")
+
+// DeriveBIP85Entropy derives the 64 bytes of BIP0085 entropy of the passed
+// application path from the root key. Every element of the path is hardened,
+// and must be given without the hardened offset.
+func DeriveBIP85Entropy(rootKey *hdkeychain.ExtendedKey,
+	appPath ...uint32) ([]byte, error) {
+
+	key, err := rootKey.Child(bip85Purpose + hdkeychain.HardenedKeyStart)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive BIP85 key: %w", err)
+	}
+	for _, index := range appPath {
+		if index >= hdkeychain.HardenedKeyStart {
+			key.Zero()
+			return nil, fmt.Errorf("BIP85 path element %v must "+
+				"be below %v", index,
+				hdkeychain.HardenedKeyStart)
+		}
+
+		child, err := key.Child(index + hdkeychain.HardenedKeyStart)
+		key.Zero()
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive BIP85 key: %w",
+				err)
+		}
+		key = child
+	}
+	defer key.Zero()
+
+	privKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive BIP85 key: %w", err)
+	}
+	defer ZeroPrivKey(privKey)
+
+	// The private key is hashed in its fixed size 32 byte serialization.
+	serialized := privKey.Serialize()
+	defer ZeroBytes(serialized)
+
+	mac := hmac.New(sha512.New, bip85HMACKey)
+	mac.Write(serialized)
+
+	return mac.Sum(nil), nil
+}
+
+// ValidateBIP85Child checks that the passed BIP0085 application is supported,
+// and that the length of its child secret is valid, as described by
+// DeriveBIP85Child.
+func ValidateBIP85Child(app string, length uint32) error {
+	switch app {
+	case BIP85AppBIP39:
+		if length != 12 && length != 18 && length != 24 {
+			return fmt.Errorf("invalid number of BIP39 words %v, "+
+				"expected 12, 18 or 24", length)
+		}
+
+	case BIP85AppHex:
+		if length < bip85MinHexBytes || length > bip85MaxHexBytes {
+			return fmt.Errorf("invalid number of bytes %v, "+
+				"expected between %v and %v", length,
+				bip85MinHexBytes, bip85MaxHexBytes)
+		}
+
+	case BIP85AppWIF:
+
+	default:
+		return fmt.Errorf("unknown BIP85 application %q, expected "+
+			"one of: bip39, hex, wif", app)
+	}
+
+	return nil
+}
+
+// DeriveBIP85Child derives the child secret of the given BIP0085 application
+// at the passed index from the root key of the cipher seed. For the bip39
+// application, length is the number of words of the mnemonic, and for the hex
+// application, it's the number of bytes of entropy. It's ignored by the wif
+// application.
+//
+// NOTE: The child secret gives full control over any funds it's used for, so
+// it's up to the caller to make sure it's meant to be printed.
+func DeriveBIP85Child(cipherSeed *aezeed.CipherSeed, params *NetParams,
+	app string, length, index uint32) (string, error) {
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return "", fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	defer rootKey.Zero()
+
+	return deriveBIP85Child(rootKey, params, app, length, index)
+}
+
+// deriveBIP85Child derives the child secret of the given BIP0085 application
+// at the passed index from the root key, as described by DeriveBIP85Child.
+func deriveBIP85Child(rootKey *hdkeychain.ExtendedKey, params *NetParams,
+	app string, length, index uint32) (string, error) {
+
+	if err := ValidateBIP85Child(app, length); err != nil {
+		return "", err
+	}
+
+	switch app {
+	case BIP85AppBIP39:
+		entropy, err := DeriveBIP85Entropy(
+			rootKey, bip85BIP39Number, bip85EnglishLanguage,
+			length, index,
+		)
+		if err != nil {
+			return "", err
+		}
+		defer ZeroBytes(entropy)
+
+		// Every 3 words encode 4 bytes of entropy, with the rest of
+		// the last word making up the checksum.
+		words, err := EntropyToBIP39(entropy[:length/3*4])
+		if err != nil {
+			return "", err
+		}
+
+		return strings.Join(words, " "), nil
+
+	case BIP85AppHex:
+		entropy, err := DeriveBIP85Entropy(
+			rootKey, bip85HexNumber, length, index,
+		)
+		if err != nil {
+			return "", err
+		}
+		defer ZeroBytes(entropy)
+
+		return hex.EncodeToString(entropy[:length]), nil
+
+	case BIP85AppWIF:
+		entropy, err := DeriveBIP85Entropy(
+			rootKey, bip85WIFNumber, index,
+		)
+		if err != nil {
+			return "", err
+		}
+		defer ZeroBytes(entropy)
+
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), entropy[:32])
+		defer ZeroPrivKey(privKey)
+
+		wif, err := btcutil.NewWIF(privKey, params.Params, true)
+		if err != nil {
+			return "", fmt.Errorf("unable to encode WIF: %w", err)
+		}
+
+		return wif.String(), nil
+
+	default:
+		return "", fmt.Errorf("unknown BIP85 application %q", app)
+	}
+}
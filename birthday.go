@@ -0,0 +1,143 @@
+package aezeedcheck
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// ErrImplausibleBirthday is returned when a seed deciphers to a birthday that
+// can't be right, which hints at a corrupt seed or a wrong passphrase that the
+// checksum of the mnemonic didn't catch.
+var ErrImplausibleBirthday = errors.New("implausible birthday")
+
+// ErrBirthdayBeforeMin is returned when a seed deciphers to a birthday before
+// the MinBirthday of the config.
+var ErrBirthdayBeforeMin = errors.New("seed too old")
+
+const (
+	// BirthdayFormatRFC3339 prints the birthday as an RFC3339 timestamp.
+	BirthdayFormatRFC3339 = "rfc3339"
+
+	// BirthdayFormatUnix prints the birthday as a unix timestamp.
+	BirthdayFormatUnix = "unix"
+
+	// BirthdayFormatDate prints only the date of the birthday, which is
+	// all the aezeed encodes anyway.
+	BirthdayFormatDate = "date"
+)
+
+// FormatBirthday formats the birthday in the given format, which is either
+// one of the BirthdayFormat constants or empty for Go's default format.
+func FormatBirthday(birthday time.Time, format string) string {
+	switch format {
+	case BirthdayFormatRFC3339:
+		return birthday.Format(time.RFC3339)
+
+	case BirthdayFormatUnix:
+		return strconv.FormatInt(birthday.Unix(), 10)
+
+	case BirthdayFormatDate:
+		return birthday.Format("2006-01-02")
+
+	default:
+		return birthday.String()
+	}
+}
+
+// validateBirthdayFormat checks that the format is one that FormatBirthday
+// knows about.
+func validateBirthdayFormat(format string) error {
+	switch format {
+	case "", BirthdayFormatRFC3339, BirthdayFormatUnix, BirthdayFormatDate:
+		return nil
+	}
+
+	return fmt.Errorf("unknown birthday format %q, expected one of: "+
+		"rfc3339, unix, date", format)
+}
+
+// BirthdayDays returns the number of whole days from the aezeed epoch, which is
+// the time of the genesis block, to t. This is how an aezeed encodes its
+// birthday, so a seed created at t deciphers to the epoch plus that many days.
+// Times before the epoch result in a negative number of days.
+func BirthdayDays(t time.Time) int64 {
+	const day = 24 * time.Hour
+
+	offset := t.Sub(aezeed.BitcoinGenesisDate)
+	days := int64(offset / day)
+	if offset < 0 && offset%day != 0 {
+		days--
+	}
+
+	return days
+}
+
+// checkBirthday returns an error wrapping ErrImplausibleBirthday if the passed
+// birthday of a seed lies after now, or before the aezeed epoch, which is the
+// time of the genesis block.
+func checkBirthday(birthday, now time.Time) error {
+	switch {
+	case birthday.After(now):
+		return fmt.Errorf("%w: the birthday %v is in the future, so "+
+			"the seed may be corrupt or the passphrase wrong, "+
+			"even though it deciphered", ErrImplausibleBirthday,
+			birthday)
+
+	case birthday.Before(aezeed.BitcoinGenesisDate):
+		return fmt.Errorf("%w: the birthday %v is before the aezeed "+
+			"epoch, so the seed may be corrupt or the passphrase "+
+			"wrong, even though it deciphered",
+			ErrImplausibleBirthday, birthday)
+	}
+
+	return nil
+}
+
+// CheckBirthday checks that the passed birthday of a deciphered seed is
+// plausible. If it isn't, the returned error wraps ErrImplausibleBirthday when
+// StrictBirthday is set, otherwise the problem is only reported as a warning.
+// If MinBirthday is set, a birthday before it is always rejected with an error
+// wrapping ErrBirthdayBeforeMin. As the aezeed only encodes whole days since
+// its epoch, both are compared by their day, so a seed created at the minimum
+// birthday is accepted even though it deciphers to an earlier time.
+func (c *Config) CheckBirthday(birthday time.Time) error {
+	err := checkBirthday(birthday, time.Now())
+	switch {
+	case err != nil && c.StrictBirthday:
+		return err
+
+	case err != nil:
+		c.warnf("%v", err)
+	}
+
+	if !c.MinBirthday.IsZero() &&
+		BirthdayDays(birthday) < BirthdayDays(c.MinBirthday) {
+
+		return fmt.Errorf("%w: the birthday %v is before the "+
+			"minimum birthday %v", ErrBirthdayBeforeMin,
+			FormatBirthday(birthday, c.BirthdayFormat),
+			FormatBirthday(c.MinBirthday, c.BirthdayFormat))
+	}
+
+	return nil
+}
+
+// EstimateBlockHeight estimates the height of the Bitcoin mainnet block that
+// was mined at the passed time, from the genesis time and the target block
+// spacing. As blocks have been found faster than the target on average, the
+// estimate is usually below the actual height, which makes it a safe height
+// to start a rescan from. Times before the genesis block map to height 0.
+func EstimateBlockHeight(t time.Time) uint32 {
+	genesis := chaincfg.MainNetParams.GenesisBlock.Header.Timestamp
+	if !t.After(genesis) {
+		return 0
+	}
+
+	spacing := chaincfg.MainNetParams.TargetTimePerBlock
+	return uint32(t.Sub(genesis) / spacing)
+}
@@ -0,0 +1,263 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+const (
+	// bsmSigSize is the size of a compact signature, which is a header
+	// byte followed by the r and s values of the signature.
+	bsmSigSize = 65
+
+	// bsmHeaderBase is the lowest header byte of a compact signature,
+	// which is the one of an uncompressed p2pkh key.
+	bsmHeaderBase = 27
+
+	// bsmHeaderMax is the highest header byte of a compact signature,
+	// which is the one of a p2wpkh key as defined in BIP0137.
+	bsmHeaderMax = bsmHeaderBase + 15
+
+	// bsmMessagePrefix is the prefix of every message signed in the
+	// Bitcoin Signed Message format.
+	bsmMessagePrefix = "Bitcoin Signed Message:\n"
+
+	// bsmNestedSegwitOffset is the amount the header byte of a compact
+	// signature is increased by for p2sh-p2wpkh addresses, as defined in
+	// BIP0137.
+	bsmNestedSegwitOffset = 4
+
+	// bsmNativeSegwitOffset is the amount the header byte of a compact
+	// signature is increased by for p2wpkh addresses, as defined in
+	// BIP0137.
+	bsmNativeSegwitOffset = 8
+)
+
+// bsmMessageDigest returns the digest that's signed for the passed message in
+// the Bitcoin Signed Message format.
+func bsmMessageDigest(msg []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	err := wire.WriteVarString(&buf, 0, bsmMessagePrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := wire.WriteVarBytes(&buf, 0, msg); err != nil {
+		return nil, err
+	}
+
+	return chainhash.DoubleHashB(buf.Bytes()), nil
+}
+
+// SignBitcoinMessage signs the passed message with the key behind the given
+// address in the legacy Bitcoin Signed Message format, and returns the
+// base64-encoded signature. For segwit addresses, the header byte of the
+// signature signals the address type as defined in BIP0137. Taproot addresses
+// aren't supported by the format.
+func SignBitcoinMessage(key *btcec.PrivateKey, addr btcutil.Address,
+	compressed bool, msg []byte) (string, error) {
+
+	var headerOffset byte
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+
+	case *btcutil.AddressScriptHash:
+		headerOffset = bsmNestedSegwitOffset
+
+	case *btcutil.AddressWitnessPubKeyHash:
+		headerOffset = bsmNativeSegwitOffset
+
+	default:
+		return "", fmt.Errorf("the Bitcoin Signed Message format "+
+			"doesn't support address %v", addr)
+	}
+
+	digest, err := bsmMessageDigest(msg)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := btcec.SignCompact(btcec.S256(), key, digest, compressed)
+	if err != nil {
+		return "", err
+	}
+	sig[0] += headerOffset
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyBitcoinMessage checks that the passed base64-encoded signature in the
+// Bitcoin Signed Message format was made over the message by the key behind
+// the given p2pkh, np2wkh or p2wkh address, and returns that key. Segwit
+// addresses are accepted both with the header byte of BIP0137 and with the
+// one of a compressed p2pkh key, which many wallets use for them instead.
+func VerifyBitcoinMessage(addr btcutil.Address, msg []byte, sigBase64 string,
+	params *chaincfg.Params) (*btcec.PublicKey, error) {
+
+	sig, err := base64.StdEncoding.DecodeString(sigBase64)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode signature: %w", err)
+	}
+	if len(sig) != bsmSigSize {
+		return nil, fmt.Errorf("expected a %v byte signature, "+
+			"instead got %v bytes", bsmSigSize, len(sig))
+	}
+	if sig[0] < bsmHeaderBase || sig[0] > bsmHeaderMax {
+		return nil, fmt.Errorf("invalid signature header byte %v",
+			sig[0])
+	}
+
+	// The key is recovered from the signature with the header byte of a
+	// compressed p2pkh key, so the offset of a segwit address type is
+	// removed first, once we've checked that it matches the address.
+	var headerOffset byte
+	switch {
+	case sig[0] >= bsmHeaderBase+4+bsmNativeSegwitOffset:
+		headerOffset = bsmNativeSegwitOffset
+
+	case sig[0] >= bsmHeaderBase+4+bsmNestedSegwitOffset:
+		headerOffset = bsmNestedSegwitOffset
+	}
+
+	_, isP2pkh := addr.(*btcutil.AddressPubKeyHash)
+	_, isNp2wkh := addr.(*btcutil.AddressScriptHash)
+	_, isP2wkh := addr.(*btcutil.AddressWitnessPubKeyHash)
+	switch {
+	case !isP2pkh && !isNp2wkh && !isP2wkh:
+		return nil, fmt.Errorf("the Bitcoin Signed Message format "+
+			"doesn't support address %v", addr)
+
+	case headerOffset == bsmNestedSegwitOffset && !isNp2wkh,
+		headerOffset == bsmNativeSegwitOffset && !isP2wkh:
+
+		return nil, fmt.Errorf("the header byte %v of the signature "+
+			"is for a different address type than %v", sig[0],
+			addr)
+	}
+
+	recoverable := make([]byte, len(sig))
+	copy(recoverable, sig)
+	recoverable[0] -= headerOffset
+
+	digest, err := bsmMessageDigest(msg)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, compressed, err := btcec.RecoverCompact(
+		btcec.S256(), recoverable, digest,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recover key: %w", err)
+	}
+
+	var signerAddr btcutil.Address
+	switch {
+	case isP2pkh:
+		signerAddr, err = KeyToP2pkhAddr(pubKey, compressed, params)
+
+	case !compressed:
+		return nil, errors.New("the signature was made by an " +
+			"uncompressed key, which can't be behind a segwit " +
+			"address")
+
+	case isNp2wkh:
+		signerAddr, err = KeyToNp2wkhAddr(pubKey, params)
+
+	default:
+		signerAddr, err = KeyToP2wkhAddr(pubKey, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if signerAddr.EncodeAddress() != addr.EncodeAddress() {
+		return nil, fmt.Errorf("the signature recovers to the key "+
+			"of %v, not of %v", signerAddr, addr)
+	}
+
+	return pubKey, nil
+}
+
+// SignAddrMessage derives the key of the address of the given type, e.g.
+// p2wkh, at the passed index of the external branch of its account, and signs
+// the message with it in the Bitcoin Signed Message format. Along with the
+// base64-encoded signature, the address it was made for is returned, so a
+// verifier can check it.
+func SignAddrMessage(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	addrTypeName string, index uint32,
+	msg []byte) (btcutil.Address, string, error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		t     addrType
+		found bool
+	)
+	for _, candidate := range addrTypes(cfg) {
+		if candidate.name == addrTypeName {
+			t, found = candidate, true
+		}
+	}
+	if !found {
+		return nil, "", fmt.Errorf("unknown address type %q",
+			addrTypeName)
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to make HD priv root: %w",
+			err)
+	}
+	defer rootKey.Zero()
+
+	accountKey, err := DeriveAccountKey(
+		rootKey, t.purpose, params.CoinType, 0,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer accountKey.Zero()
+
+	branchKey, err := accountKey.Child(ExternalBranch)
+	if err != nil {
+		return nil, "", err
+	}
+	defer branchKey.Zero()
+
+	child, err := branchKey.Child(index)
+	if err != nil {
+		return nil, "", err
+	}
+	defer child.Zero()
+
+	privKey, err := child.ECPrivKey()
+	if err != nil {
+		return nil, "", err
+	}
+	defer ZeroPrivKey(privKey)
+	addr, err := t.keyToAddr(privKey.PubKey(), params.Params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sig, err := SignBitcoinMessage(privKey, addr, t.compressed, msg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return addr, sig, nil
+}
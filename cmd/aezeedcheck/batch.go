@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// batchSeed is a single mnemonic read from the batch file, along with its
+// passphrase.
+type batchSeed struct {
+	// line is the line number of the mnemonic in the batch file.
+	line int
+
+	// words are the words of the mnemonic.
+	words []string
+
+	// pass is the passphrase of the mnemonic.
+	pass []byte
+}
+
+// jsonBatchEntry is the JSON encoding of the result of a single mnemonic of
+// the batch file. Exactly one of Result and Error is set.
+type jsonBatchEntry struct {
+	Line   int             `json:"line"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// parseBatchSeeds parses the content of a batch file, where each line is a
+// mnemonic, optionally followed by a tab and its passphrase. Empty lines and
+// lines starting with # are skipped. Lines without a passphrase use the one
+// given with --pass, if any.
+func parseBatchSeeds(content []byte) []batchSeed {
+	var seeds []batchSeed
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" ||
+			strings.HasPrefix(strings.TrimSpace(line), "#") {
+
+			continue
+		}
+
+		pass := *aezeedPass
+		if tab := strings.Index(line, "\t"); tab >= 0 {
+			line, pass = line[:tab], line[tab+1:]
+		}
+
+		seeds = append(seeds, batchSeed{
+			line:  i + 1,
+			words: strings.Fields(line),
+			pass:  []byte(pass),
+		})
+	}
+
+	return seeds
+}
+
+// runBatchSeed runs the recovery of a single mnemonic of the batch file with
+// the passed config, writing its results to w. To avoid leaking any part of
+// the mnemonic, errors never include its words.
+func runBatchSeed(w io.Writer, cfg aezeedcheck.Config, seed batchSeed) error {
+	if aezeedcheck.LooksLikeBIP39(seed.words) {
+		return errBIP39Seed
+	}
+	if len(seed.words) != aezeed.NummnemonicWords {
+		return fmt.Errorf("expected %v words, instead got %v",
+			aezeed.NummnemonicWords, len(seed.words))
+	}
+
+	var numUnknown int
+	for i, word := range seed.words {
+		seed.words[i] = strings.ToLower(word)
+		if !aezeedcheck.IsWord(seed.words[i]) {
+			numUnknown++
+		}
+	}
+	if numUnknown > 0 {
+		return fmt.Errorf("%v word(s) not part of the aezeed word list",
+			numUnknown)
+	}
+
+	copy(cfg.Mnemonic[:], seed.words)
+	cfg.Passphrase = seed.pass
+
+	return withExitCode(exitDerive, aezeedcheck.Run(cfg, w))
+}
+
+// runBatch runs the recovery of each mnemonic of the batch file given with
+// --batch-file, printing a result block per mnemonic, or a JSON array with an
+// entry per mnemonic. A mnemonic that fails doesn't stop the batch, instead
+// its error is recorded in its result. Once done, a summary is printed to
+// stderr, and an error is returned if any mnemonic failed.
+func runBatch(w io.Writer, cfg aezeedcheck.Config) error {
+	content, err := ioutil.ReadFile(*batchFile)
+	if err != nil {
+		return fmt.Errorf("unable to read batch file: %w", err)
+	}
+	defer aezeedcheck.ZeroBytes(content)
+
+	seeds := parseBatchSeeds(content)
+	defer func() {
+		for _, seed := range seeds {
+			aezeedcheck.ZeroBytes(seed.pass)
+		}
+	}()
+	if len(seeds) == 0 {
+		return fmt.Errorf("no mnemonics found in %v", *batchFile)
+	}
+
+	var (
+		entries  []jsonBatchEntry
+		numFails int
+		firstErr error
+	)
+	for _, seed := range seeds {
+		var (
+			result bytes.Buffer
+			out    io.Writer = &result
+		)
+		if cfg.Format != aezeedcheck.FormatJSON {
+			fmt.Fprintf(w, "=== line %v ===\n", seed.line)
+			out = w
+		}
+
+		err := runBatchSeed(out, cfg, seed)
+		if err != nil {
+			numFails++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+
+		switch {
+		case cfg.Format == aezeedcheck.FormatJSON && err != nil:
+			entries = append(entries, jsonBatchEntry{
+				Line:  seed.line,
+				Error: err.Error(),
+			})
+
+		case cfg.Format == aezeedcheck.FormatJSON:
+			entries = append(entries, jsonBatchEntry{
+				Line:   seed.line,
+				Result: result.Bytes(),
+			})
+
+		case err != nil:
+			fmt.Fprintf(w, "error: %v\n", err)
+		}
+
+		if cfg.Format != aezeedcheck.FormatJSON {
+			fmt.Fprintln(w)
+		}
+	}
+
+	if cfg.Format == aezeedcheck.FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "processed %v seeds: %v succeeded, %v failed\n",
+		len(seeds), len(seeds)-numFails, numFails)
+
+	if numFails > 0 {
+		return withExitCode(exitCode(firstErr), fmt.Errorf("%v of %v "+
+			"seeds failed", numFails, len(seeds)))
+	}
+
+	return nil
+}
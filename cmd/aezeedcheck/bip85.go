@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// bip85Length returns the length of the BIP0085 child secret selected on the
+// command line, which depends on its application.
+func bip85Length() uint32 {
+	switch *bip85App {
+	case aezeedcheck.BIP85AppBIP39:
+		return uint32(*bip85Words)
+
+	case aezeedcheck.BIP85AppHex:
+		return uint32(*bip85Bytes)
+
+	default:
+		return 0
+	}
+}
+
+// printBIP85Child derives the BIP0085 child secret selected on the command
+// line from the passed cipher seed, and prints it.
+func printBIP85Child(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	length := bip85Length()
+	child, err := aezeedcheck.DeriveBIP85Child(
+		cipherSeed, params, *bip85App, length, uint32(*bip85Index),
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"BIP85 child: %w", err))
+	}
+
+	switch *bip85App {
+	case aezeedcheck.BIP85AppBIP39:
+		fmt.Fprintf(w, "BIP85 child mnemonic (%v words, index %v): "+
+			"%v\n", length, *bip85Index, child)
+
+	case aezeedcheck.BIP85AppHex:
+		fmt.Fprintf(w, "BIP85 child entropy (%v bytes, index %v): "+
+			"%v\n", length, *bip85Index, child)
+
+	default:
+		fmt.Fprintf(w, "BIP85 child WIF (index %v): %v\n",
+			*bip85Index, child)
+	}
+
+	return nil
+}
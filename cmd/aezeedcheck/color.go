@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/btcsuite/golangcrypto/ssh/terminal"
+)
+
+const (
+	// colorAuto enables color if stderr is a terminal and the NO_COLOR
+	// environment variable isn't set.
+	colorAuto = "auto"
+
+	// colorAlways always enables color.
+	colorAlways = "always"
+
+	// colorNever always disables color.
+	colorNever = "never"
+
+	// ansiWarning is the ANSI escape sequence that highlights the label of
+	// a warning in bold yellow.
+	ansiWarning = "\x1b[1;33m"
+
+	// ansiNote is the ANSI escape sequence that highlights the label of a
+	// note in bold cyan.
+	ansiNote = "\x1b[1;36m"
+
+	// ansiReset is the ANSI escape sequence that resets all attributes.
+	ansiReset = "\x1b[0m"
+)
+
+// useColor signals that the labels of warnings and notes should be colored.
+var useColor bool
+
+// setupColor decides whether color should be used in the passed --color mode.
+func setupColor(mode string) error {
+	switch mode {
+	case colorAuto:
+		_, noColor := os.LookupEnv("NO_COLOR")
+		useColor = !noColor && terminal.IsTerminal(int(os.Stderr.Fd()))
+
+	case colorAlways:
+		useColor = true
+
+	case colorNever:
+		useColor = false
+
+	default:
+		return fmt.Errorf("unknown color mode %q, expected one of: "+
+			"auto, always, never", mode)
+	}
+
+	return nil
+}
+
+// colorize wraps the passed label in the given ANSI escape sequence if color
+// is enabled.
+func colorize(ansi, label string) string {
+	if !useColor {
+		return label
+	}
+
+	return ansi + label + ansiReset
+}
+
+// warnf prints a warning to stderr, with only its label colored, so the
+// message itself stays clean when copied.
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%v %v\n", colorize(ansiWarning, "WARNING:"),
+		fmt.Sprintf(format, args...))
+}
+
+// notef prints a note to stderr, with only its label colored.
+func notef(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%v %v\n", colorize(ansiNote, "NOTE:"),
+		fmt.Sprintf(format, args...))
+}
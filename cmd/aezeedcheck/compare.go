@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// readCompareSeed reads the mnemonic passed to --compare, or from stdin if
+// it's -, and deciphers it with the passphrase passed to --pass2.
+func readCompareSeed() (*aezeed.CipherSeed, error) {
+	var (
+		words []string
+		raw   []byte
+		err   error
+	)
+	if *compareMnemonic == mnemonicStdin {
+		words, raw, err = readMnemonicWords(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read second "+
+				"mnemonic: %w", err)
+		}
+		defer aezeedcheck.ZeroBytes(raw)
+	} else {
+		warnf("passing the second mnemonic on the command line leaks " +
+			"it into the process table, use --compare=- to read " +
+			"it from stdin instead")
+		words = strings.Fields(*compareMnemonic)
+	}
+
+	if aezeedcheck.LooksLikeBIP39(words) {
+		return nil, fmt.Errorf("invalid second mnemonic: %w",
+			errBIP39Seed)
+	}
+	if len(words) != aezeed.NummnemonicWords {
+		return nil, fmt.Errorf("invalid second mnemonic: %w",
+			wordCountError(words))
+	}
+	if err := normalizeMnemonic(words); err != nil {
+		return nil, fmt.Errorf("invalid second mnemonic: %w", err)
+	}
+
+	var m aezeed.Mnemonic
+	copy(m[:], words)
+
+	password := []byte(*comparePass)
+	defer aezeedcheck.ZeroBytes(password)
+
+	cipherSeed, err := m.ToCipherSeed(password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt second cipher "+
+			"seed: %w", err)
+	}
+
+	return cipherSeed, nil
+}
+
+// compareSeeds compares the passed cipher seed to the one given with
+// --compare, and prints each compared field to w. If any of them differ, then
+// an error is returned after all of them are printed.
+func compareSeeds(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	otherSeed, err := readCompareSeed()
+	if err != nil {
+		return err
+	}
+	defer aezeedcheck.ZeroCipherSeed(otherSeed)
+
+	fields, err := aezeedcheck.CompareSeeds(cfg, cipherSeed, otherSeed)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to "+
+			"compare seeds: %w", err))
+	}
+
+	var numDiffs int
+	for _, f := range fields {
+		switch {
+		case f.Match && f.A == "":
+			fmt.Fprintf(w, "%v: match\n", f.Name)
+
+		case f.Match:
+			fmt.Fprintf(w, "%v: match (%v)\n", f.Name, f.A)
+
+		case f.A == "":
+			numDiffs++
+			fmt.Fprintf(w, "%v: DIFFERS\n", f.Name)
+
+		default:
+			numDiffs++
+			fmt.Fprintf(w, "%v: DIFFERS\n  first:  %v\n  second: "+
+				"%v\n", f.Name, f.A, f.B)
+		}
+	}
+
+	if numDiffs > 0 {
+		return withExitCode(exitDerive, fmt.Errorf("the seeds "+
+			"differ in %v of %v field(s)", numDiffs, len(fields)))
+	}
+
+	fmt.Fprintln(w, "the seeds match")
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+const (
+	// exitUsage is the exit code of a malformed invocation, including
+	// missing or invalid input.
+	exitUsage = 1
+
+	// exitDecrypt is the exit code used when the seed can't be
+	// deciphered, e.g. because of a wrong passphrase.
+	exitDecrypt = 2
+
+	// exitDerive is the exit code used when the keys can't be derived
+	// from the seed, or don't match the expected ones.
+	exitDerive = 3
+
+	// exitBirthday is the exit code used when the seed deciphers to a
+	// birthday before the one given with --min-birthday.
+	exitBirthday = 4
+)
+
+// exitCodesHelp documents the exit codes in the usage message, so scripts
+// wrapping the tool know what to expect.
+const exitCodesHelp = `
+Exit codes:
+  0  success
+  1  usage error, e.g. missing or invalid input
+  2  the seed couldn't be deciphered, e.g. because of a wrong passphrase or
+     a mistyped word
+  3  the keys couldn't be derived, or didn't match the expected ones
+  4  the birthday of the seed is before --min-birthday
+`
+
+// exitError is an error that determines the exit code of the tool.
+type exitError struct {
+	code int
+	err  error
+}
+
+// Error returns the message of the wrapped error.
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode wraps the passed error, if any, so the tool exits with the
+// given code.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the code the tool should exit with for the passed error.
+// Any failure to decipher the seed is detected by the errors of the aezeed
+// package, no matter where it happened, and so is a birthday rejected by
+// --strict-birthday or --min-birthday. Errors that weren't assigned a code
+// are considered usage errors.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, aezeedcheck.ErrBirthdayBeforeMin):
+		return exitBirthday
+
+	case errors.Is(err, aezeed.ErrInvalidPass),
+		errors.Is(err, aezeed.ErrIncorrectMnemonic),
+		errors.Is(err, aezeed.ErrIncorrectVersion),
+		errors.Is(err, aezeedcheck.ErrImplausibleBirthday):
+
+		return exitDecrypt
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return exitUsage
+}
+
+// hiddenFlags is the set of flags that are left out of the usage message, as
+// they're only meant for the development of the tool.
+var hiddenFlags = map[string]bool{
+	"pprof": true,
+}
+
+// usage prints the usage message of the tool, including its exit codes.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+
+	// The defaults of the visible flags are printed from a copy of them,
+	// as the flag package has no notion of hidden flags.
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if hiddenFlags[f.Name] {
+			return
+		}
+		visible.Var(f.Value, f.Name, f.Usage)
+		visible.Lookup(f.Name).DefValue = f.DefValue
+	})
+	visible.PrintDefaults()
+
+	fmt.Fprint(out, exitCodesHelp)
+}
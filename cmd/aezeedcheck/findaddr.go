@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// parseTargetAddr decodes the address passed to --contains-address, which
+// must be for the selected coin and network.
+func parseTargetAddr(cfg *aezeedcheck.Config,
+	addrStr string) (btcutil.Address, error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := aezeedcheck.DecodeAddress(addrStr, params.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsForNet(params.Params) {
+		return nil, fmt.Errorf("address %v isn't for %v %v", addr,
+			*coin, *network)
+	}
+
+	return addr, nil
+}
+
+// printContainsAddr searches the addresses derived from the cipher seed for
+// the one passed to --contains-address, and prints where it was found. If it
+// isn't found, an error stating how far the search went is returned instead.
+func printContainsAddr(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed, target btcutil.Address) error {
+
+	match, err := aezeedcheck.FindAddr(cfg, cipherSeed, target)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to search "+
+			"addresses: %w", err))
+	}
+	if match == nil {
+		return withExitCode(exitDerive, fmt.Errorf("address %v not "+
+			"found in the %v addresses starting at index %v of "+
+			"each selected address type, branch and account, "+
+			"try a larger --count", target.EncodeAddress(),
+			cfg.Count, cfg.FirstIndex))
+	}
+
+	fmt.Fprintf(w, "Address %v belongs to the seed\n",
+		target.EncodeAddress())
+	fmt.Fprintf(w, "Type: %v\nAccount: %v\nBranch: %v\nIndex: %v\n"+
+		"Path: %v\n", match.AddrType, match.Path.Account,
+		match.Path.Branch, match.Path.Index, match.Path)
+
+	return nil
+}
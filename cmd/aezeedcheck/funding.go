@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// printFundingAddrs derives the funding address that each of the first
+// --funding-count multisig keys of the cipher seed forms with the multisig key
+// passed to --remote-pubkey, and prints them.
+func printFundingAddrs(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed, remotePub []byte) error {
+
+	remoteKey, err := btcec.ParsePubKey(remotePub, btcec.S256())
+	if err != nil {
+		return fmt.Errorf("invalid --remote-pubkey: %w", err)
+	}
+
+	candidates, err := aezeedcheck.DeriveFundingCandidates(
+		cfg, cipherSeed, remoteKey, uint32(*fundingCount),
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"funding addresses: %w", err))
+	}
+
+	for _, c := range candidates {
+		fmt.Fprintf(w, "funding address #%v: %v [local multisig "+
+			"key %x at %v]\n", c.Path.Index, c.Addr.EncodeAddress(),
+			c.LocalKey.SerializeCompressed(), c.Path)
+	}
+
+	return nil
+}
@@ -0,0 +1,1450 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+var (
+	// mnemonic is the user's aezeed paas phrase in full.
+	mnemonic = flag.String("mnemonic", "", "your aezeed mnemonic with "+
+		"each word separated by a new line, or - to read it from stdin")
+
+	// mnemonicFile is the path of a file the mnemonic should be read
+	// from.
+	mnemonicFile = flag.String("mnemonic-file", "", "the path of a file "+
+		"to read the aezeed mnemonic from, with the words separated "+
+		"by spaces or new lines")
+
+	// mnemonicEnv is the name of an environment variable the mnemonic
+	// should be read from.
+	mnemonicEnv = flag.String("mnemonic-env", "", "the name of an "+
+		"environment variable to read the aezeed mnemonic from, e.g. "+
+		"AEZEED_MNEMONIC, which unlike --mnemonic doesn't leak it "+
+		"into the process table")
+
+	// batchFile is the path of a file listing mnemonics, one per line,
+	// that should each be recovered in turn.
+	batchFile = flag.String("batch-file", "", "the path of a file "+
+		"listing aezeed mnemonics to recover, one per line, each "+
+		"optionally followed by a tab and its passphrase")
+
+	// readStdin signals that the mnemonic should be read from stdin.
+	readStdin = flag.Bool("stdin", false, "read the aezeed mnemonic "+
+		"from stdin, with the words separated by spaces or new lines")
+
+	// watchXpub is an account extended public key to derive addresses
+	// from in watch-only mode, without the seed.
+	watchXpub = flag.String("watch-xpub", "", "derive addresses from "+
+		"this account extended public key, without the seed, "+
+		"detecting the address type from its version bytes")
+
+	// watchAddrType overrides the type of the addresses derived from
+	// watchXpub.
+	watchAddrType = flag.String("watch-addr-type", "", "the type of the "+
+		"addresses derived from --watch-xpub (p2wkh, np2wkh, p2tr, "+
+		"p2pkh), overriding the detected one")
+
+	// aezeedPass is an optional passphrase that may be required to
+	// properly decrypt an aezeed if it was created with a passphrase.
+	aezeedPass = flag.String("pass", "", "an optional password used to "+
+		"encrypt the aezeed pass phrase")
+
+	// passEnv is the name of an environment variable the passphrase
+	// should be read from.
+	passEnv = flag.String("pass-env", "", "the name of an environment "+
+		"variable to read the aezeed passphrase from, e.g. "+
+		"AEZEED_PASS, which unlike --pass doesn't leak it into the "+
+		"process table, takes precedence over --pass if the variable "+
+		"is set")
+
+	// compareMnemonic is a second mnemonic that the seed should be
+	// compared to.
+	compareMnemonic = flag.String("compare", "", "only compare the "+
+		"entropy, node pubkey and first addresses of the seed to "+
+		"those of this second aezeed mnemonic, or - to read it from "+
+		"stdin, exiting with a non-zero status if any of them differ")
+
+	// comparePass is the passphrase of the mnemonic passed to
+	// --compare.
+	comparePass = flag.String("pass2", "", "the passphrase of the "+
+		"mnemonic passed to --compare")
+
+	// dryRun signals that only the derivation paths of the addresses
+	// should be printed, without deriving any keys.
+	dryRun = flag.Bool("dry-run", false, "only print the derivation "+
+		"paths of the addresses that would be derived, without "+
+		"requiring a mnemonic or deriving any keys")
+
+	// fingerprintOnly signals that only the master fingerprint and node
+	// pubkey of the seed should be derived and printed.
+	fingerprintOnly = flag.Bool("fingerprint", false, "only print the "+
+		"master key fingerprint and node pubkey of the seed, to "+
+		"quickly tell which seed a backup holds")
+
+	// ecdhPeer is the pubkey of a peer to compute the ECDH shared secret
+	// of the node key with. As the shared secret is derived from the node
+	// private key, it also requires riskConfirmed to be set.
+	ecdhPeer = flag.String("ecdh", "", "only compute and print the "+
+		"ECDH shared secret of the node key and this hex-encoded peer "+
+		"pubkey, just like lnd computes it, to debug handshakes, "+
+		"requires --i-understand-the-risk")
+
+	// containsAddr is an address to search the addresses derived from the
+	// seed for, to tell whether it belongs to the seed.
+	containsAddr = flag.String("contains-address", "", "only search "+
+		"the --count addresses of each selected address type, "+
+		"branch and account for this address, and report where it "+
+		"was found")
+
+	// checkOnly signals that the mnemonic should only be validated,
+	// without deriving any keys.
+	checkOnly = flag.Bool("check", false, "only check that the "+
+		"mnemonic and passphrase are valid, without deriving any keys")
+
+	// expectNodePub is the hex-encoded node identity key the seed is
+	// expected to derive.
+	expectNodePub = flag.String("expect-node-pubkey", "", "only check "+
+		"that the seed derives the given hex-encoded node pubkey, "+
+		"exiting with a non-zero status if it doesn't")
+
+	// signMessage is a message that should be signed with the node key,
+	// just like lncli signmessage does.
+	signMessage = flag.String("sign-message", "", "only sign the given "+
+		"message with the node key and print the zbase32-encoded "+
+		"signature, just like lncli signmessage")
+
+	// signAddrIndex is the index of the address whose key should sign
+	// signMessage, instead of the node key.
+	signAddrIndex = flag.Uint("sign-addr-index", 0, "if set, sign "+
+		"--sign-message with the key of the external address at this "+
+		"index in the Bitcoin Signed Message format, instead of with "+
+		"the node key")
+
+	// signAddrType is the type of the address whose key should sign
+	// signMessage.
+	signAddrType = flag.String("sign-addr-type", "p2wkh", "the type of "+
+		"the address signing with --sign-addr-index (p2wkh, np2wkh, "+
+		"p2pkh), requires --sign-addr-index")
+
+	// challenge is a challenge, e.g. one provided by an exchange, that
+	// should be signed with the key of an address to prove ownership of
+	// it.
+	challenge = flag.String("challenge", "", "only sign this challenge "+
+		"with the key of the external address selected with "+
+		"--prove-addr-index and --prove-addr-type, and print a JSON "+
+		"proof of ownership, signed in the Bitcoin Signed Message "+
+		"format, or as a BIP322 simple signature for p2tr")
+
+	// proveAddrIndex is the index of the address whose ownership should
+	// be proven.
+	proveAddrIndex = flag.Uint("prove-addr-index", 0, "the index of the "+
+		"external address whose key signs --challenge")
+
+	// proveAddrType is the type of the address whose ownership should be
+	// proven.
+	proveAddrType = flag.String("prove-addr-type", "p2wkh", "the type "+
+		"of the address whose key signs --challenge (p2wkh, np2wkh, "+
+		"p2tr, p2pkh)")
+
+	// verifyMessage is a message whose signature by a node key, or by
+	// the key of the verifyAddr address, should be verified.
+	verifyMessage = flag.String("verify-message", "", "only verify "+
+		"the --verify-sig signature over the given message and print "+
+		"the node pubkey that signed it, or check that the key of "+
+		"the --verify address signed it, no seed is required")
+
+	// verifySig is the zbase32-encoded signature of verifyMessage by a
+	// node key, or its base64-encoded signature in the Bitcoin Signed
+	// Message format if verifyAddr is set.
+	verifySig = flag.String("verify-sig", "", "the signature to check "+
+		"with --verify-message, zbase32-encoded for a node key, or "+
+		"base64-encoded in the Bitcoin Signed Message format with "+
+		"--verify")
+
+	// verifyAddr is the address whose key should have signed
+	// verifyMessage in the Bitcoin Signed Message format.
+	verifyAddr = flag.String("verify", "", "check that the --verify-sig "+
+		"signature over --verify-message was made in the Bitcoin "+
+		"Signed Message format by the key of this p2pkh, np2wkh or "+
+		"p2wkh address")
+
+	// passList is the path of a file listing candidate passphrases of
+	// the aezeed, one per line.
+	passList = flag.String("pass-list", "", "the path of a file "+
+		"listing candidate passwords, one per line, that are tried "+
+		"until one deciphers the aezeed")
+
+	// passEmptyFirst signals that the empty passphrase should be tried
+	// before the candidates of passList.
+	passEmptyFirst = flag.Bool("pass-empty-first", false, "try the "+
+		"empty password before the ones listed in --pass-list")
+
+	// newAezeedPass is the passphrase the aezeed should be re-enciphered
+	// with.
+	newAezeedPass = flag.String("new-pass", "", "if set, re-encipher "+
+		"the aezeed with this new password and print the new "+
+		"mnemonic, set to an empty value to remove the password")
+
+	// generate signals that a new aezeed should be generated, rather than
+	// deciphering an existing one.
+	generate = flag.Bool("generate", false, "generate a new aezeed, "+
+		"enciphered with --pass, from fresh entropy and print its "+
+		"mnemonic along with the usual output")
+
+	// birthdayStr overrides the birthday of a newly created aezeed.
+	birthdayStr = flag.String("birthday", "", "the birthday of the "+
+		"aezeed created by --generate, --entropy or --slip39-shares "+
+		"as YYYY-MM-DD or RFC3339 timestamp, defaults to the current "+
+		"time")
+
+	// slip39Shares holds the SLIP39 mnemonic shares to reconstruct the
+	// entropy of a new aezeed from, collected from each --slip39-shares
+	// flag.
+	slip39Shares stringList
+
+	// slip39Pass is the passphrase the SLIP39 master secret is encrypted
+	// with.
+	slip39Pass = flag.String("slip39-pass", "", "the passphrase of the "+
+		"SLIP39 shares given with --slip39-shares or created with "+
+		"--slip39-split")
+
+	// slip39Split is the T-of-N policy of the SLIP39 shares that the
+	// entropy of the seed should be split into. As the shares give full
+	// control over all funds once enough of them are combined, it also
+	// requires riskConfirmed to be set.
+	slip39Split = flag.String("slip39-split", "", "only split the "+
+		"entropy of the seed into N SLIP39 mnemonic shares, any T of "+
+		"which recover it, with this T-of-N policy and print them, "+
+		"requires --i-understand-the-risk")
+
+	// entropyHex is the hex-encoded entropy to create an aezeed from,
+	// rather than deciphering an existing one.
+	entropyHex = flag.String("entropy", "", "create an aezeed, "+
+		"enciphered with --pass, from the given 16 bytes of "+
+		"hex-encoded entropy and print its mnemonic along with the "+
+		"usual output")
+
+	// verifyWords signals that only the words of the mnemonic should be
+	// verified against its checksum, which doesn't require the
+	// passphrase.
+	verifyWords = flag.Bool("verify-words", false, "only verify that "+
+		"the words of the mnemonic match its checksum, without the "+
+		"passphrase, to tell mistyped words apart from a wrong "+
+		"passphrase")
+
+	// printMnemonic signals that the mnemonic should be printed with one
+	// numbered word per line, which is the canonical way to write down an
+	// aezeed.
+	printMnemonic = flag.Bool("print-mnemonic", false, "print the "+
+		"mnemonic of a seed created with --generate, --entropy, "+
+		"--slip39-shares or --new-pass with one numbered word per "+
+		"line, or on its own, only check that each word of the "+
+		"given mnemonic is in the word list and reprint it that way")
+
+	// recoverWord is the position of a forgotten word in the mnemonic
+	// that should be recovered.
+	recoverWord = flag.Uint("recover-word", 0, "the position (1-24) "+
+		"of a forgotten word, given as ? in the mnemonic, that "+
+		"should be recovered by trying every word of the word list")
+
+	// coin is the coin the aezeed was used for. Along with the network, it
+	// determines the coin type and the encoding of the addresses.
+	coin = flag.String("coin", aezeedcheck.CoinBitcoin, "the coin the "+
+		"aezeed was used for (btc, ltc), where ltc is only supported "+
+		"on mainnet")
+
+	// network is the name of the network the aezeed was used on. This
+	// determines the coin type used during derivation, as well as the
+	// encoding of the final addresses.
+	network = flag.String("network", "mainnet", "the network the aezeed "+
+		"was used on (mainnet, testnet3, regtest, signet, simnet)")
+
+	// coinType overrides the coin type of all derivation paths.
+	coinType = flag.Uint("coin-type", 0, "if set, use this BIP44 coin "+
+		"type in all derivation paths instead of the one of --coin "+
+		"and --network, which only changes the paths, not the "+
+		"encoding of the addresses")
+
+	// signetHRP is an optional bech32 HRP that overrides the default one
+	// of signet, as custom signets may use their own address prefix.
+	signetHRP = flag.String("signet-hrp", "", "an optional bech32 HRP "+
+		"used for segwit addresses on custom signets, only applies "+
+		"when --network=signet")
+
+	// legacyUncompressed signals that the legacy p2pkh address should be
+	// computed from the uncompressed serialization of the key, as done by
+	// some older wallets.
+	legacyUncompressed = flag.Bool("legacy-uncompressed", false, "use "+
+		"the uncompressed public key when computing the legacy p2pkh "+
+		"address")
+
+	// uncompressed signals that the uncompressed serialization of the
+	// printed public keys should be included for legacy tooling.
+	uncompressed = flag.Bool("uncompressed", false, "also print the "+
+		"uncompressed serialization of the node pubkey and of the "+
+		"keys of --key-families and --key-family, and compute the "+
+		"legacy p2pkh address from it")
+
+	// scripts signals that the hex-encoded output script should be
+	// printed alongside each address.
+	scripts = flag.Bool("scripts", false, "also print the scriptPubKey "+
+		"of each derived address")
+
+	// addrTypesList is the comma separated list of the address types to
+	// derive.
+	addrTypesList = flag.String("addr-types", "p2wkh,np2wkh,p2tr,p2pkh",
+		"the comma separated list of address types to derive")
+
+	// count is the number of addresses to derive for each address type.
+	count = flag.Uint("count", 1, "the number of addresses to derive "+
+		"for each address type")
+
+	// indexRange is the inclusive range of address indexes to derive on
+	// each branch, instead of the first --count ones.
+	indexRange = flag.String("range", "", "derive the addresses at the "+
+		"inclusive range of indexes START:END on each branch, e.g. "+
+		"to resume an earlier recovery, instead of the first --count "+
+		"ones")
+
+	// accounts is the account index, or the range of account indexes,
+	// that addresses are derived from.
+	accounts = flag.String("accounts", "", "the account index N, or "+
+		"range of account indexes N-M, to derive addresses from "+
+		"(default 0)")
+
+	// branch selects which branches of each account addresses are derived
+	// from.
+	branch = flag.String("branch", "external", "the branch to derive "+
+		"addresses from (external, internal, both)")
+
+	// gapLimit is the number of addresses derived past --count on each
+	// branch when printing a batch for bulk import.
+	gapLimit = flag.Uint("gap-limit", 20, "if set, derive --count plus "+
+		"this many addresses on each branch and print them along "+
+		"with their derivation path in a format suitable for bulk "+
+		"import")
+
+	// format is the format the results are printed in.
+	format = flag.String("format", aezeedcheck.FormatText, "the "+
+		"output format (text, json, csv, table, bitcoind, electrum, "+
+		"sparrow, coldcard)")
+
+	// colorMode determines whether the labels of warnings are colored.
+	colorMode = flag.String("color", colorAuto, "whether to color the "+
+		"labels of warnings on stderr (auto, always, never), where "+
+		"auto only colors them on a terminal if NO_COLOR isn't set, "+
+		"keys and addresses are never colored")
+
+	// quiet signals that no progress indicators should be printed to
+	// stderr.
+	quiet = flag.Bool("quiet", false, "don't print the progress of "+
+		"scans, passphrase lists and word recovery to stderr, which "+
+		"is only printed if stderr is a terminal")
+
+	// birthdayFormat is the format the birthday of the seed is printed
+	// in.
+	birthdayFormat = flag.String("birthday-format", "", "the format "+
+		"the wallet birthday is printed in by the text and csv "+
+		"formats (rfc3339, unix, date), Go's default if unset")
+
+	// strictBirthday signals that a seed with an implausible birthday
+	// should be rejected instead of only causing a warning.
+	strictBirthday = flag.Bool("strict-birthday", false, "fail if the "+
+		"seed deciphers to a birthday in the future or before the "+
+		"aezeed epoch, which hints at a corrupt seed or a wrong "+
+		"passphrase, instead of only printing a warning")
+
+	// minBirthdayStr is the earliest birthday of the seeds to accept.
+	minBirthdayStr = flag.String("min-birthday", "", "fail if the "+
+		"seed deciphers to a birthday before this YYYY-MM-DD date or "+
+		"RFC3339 timestamp, to only process seeds created after a "+
+		"cutoff")
+
+	// csvComments signals that the birthday and node key should be
+	// included as comment lines when printing CSV.
+	csvComments = flag.Bool("csv-comments", false, "include the "+
+		"birthday, master fingerprint and node key as comment lines "+
+		"prefixed with # when using --format=csv")
+
+	// keyFamilies signals that the first key of each of lnd's key
+	// families should be printed.
+	keyFamilies = flag.Bool("key-families", false, "also print the "+
+		"first public key of each of lnd's key families")
+
+	// keyFamily is the key family of the single key that should be
+	// derived, much like lnd's keychain.KeyLocator.
+	keyFamily = flag.Uint("key-family", 0, "if set, also derive and "+
+		"print the single key of this key family at --key-index")
+
+	// keyIndex is the index of the single key that should be derived.
+	keyIndex = flag.Uint("key-index", 0, "the index of the key derived "+
+		"with --key-family")
+
+	// keyPrivKey signals that the private key of the single key should be
+	// printed as well.
+	keyPrivKey = flag.Bool("key-privkey", false, "also print the "+
+		"private key of the key derived with --key-family, requires "+
+		"--i-understand-the-risk")
+
+	// towerSessions is the number of watchtower client session keys that
+	// should be derived.
+	towerSessions = flag.Uint("tower-sessions", 0, "if set, also print "+
+		"this many session keys of lnd's watchtower client, along "+
+		"with the identity key of lnd's watchtower, following the "+
+		"key family layout of lnd v0.7")
+
+	// scbFile is the path of a static channel backup that should be
+	// decrypted with the seed.
+	scbFile = flag.String("scb-file", "", "the path of an lnd "+
+		"channel.backup file to decrypt with the seed, printing the "+
+		"channels it contains")
+
+	// scbDir is the path of a directory that's searched for static
+	// channel backups to decrypt with the seed.
+	scbDir = flag.String("scb-dir", "", "the path of a directory, e.g. "+
+		"a copy of lnd's data directory, to search for .backup files "+
+		"to decrypt with the seed, printing the unique channels they "+
+		"contain and skipping files that can't be decrypted")
+
+	// xpub signals that the extended public key of each account should be
+	// printed, for use with watch-only wallets.
+	xpub = flag.Bool("xpub", false, "also print the account extended "+
+		"public key of each address type")
+
+	// slip132 signals that the account extended public keys should be
+	// encoded with the SLIP-0132 version bytes of their key scope.
+	slip132 = flag.Bool("slip132", false, "encode the account extended "+
+		"public keys printed by --xpub with SLIP-0132 version bytes "+
+		"(zpub/ypub on mainnet, vpub/upub on test networks)")
+
+	// descriptors signals that the output descriptors of each account
+	// should be printed.
+	descriptors = flag.Bool("descriptors", false, "also print the "+
+		"external and internal output descriptors of each address "+
+		"type, and the descriptor of each p2tr address with its "+
+		"explicit internal key")
+
+	// xprv signals that the extended private key of each account should
+	// be printed. As this exposes private key material, it also requires
+	// riskConfirmed to be set.
+	xprv = flag.Bool("xprv", false, "also print the account extended "+
+		"private key of each address type, requires "+
+		"--i-understand-the-risk")
+
+	// wif signals that the WIF encoded private key of each derived address
+	// should be printed. As this exposes private key material, it also
+	// requires riskConfirmed to be set.
+	wif = flag.Bool("wif", false, "also print the WIF encoded private "+
+		"key of each derived address, requires --i-understand-the-risk")
+
+	// showEntropy signals that the raw entropy of the seed should be
+	// printed. As this exposes the seed itself, it also requires
+	// riskConfirmed to be set.
+	showEntropy = flag.Bool("show-entropy", false, "also print the raw "+
+		"hex-encoded entropy of the seed, requires "+
+		"--i-understand-the-risk")
+
+	// bip39 signals that the entropy of the seed should be printed as a
+	// BIP0039 mnemonic. As this exposes the seed itself, it also requires
+	// riskConfirmed to be set.
+	bip39 = flag.Bool("bip39", false, "also print the entropy of the "+
+		"seed as a 12 word BIP39 mnemonic, which derives different "+
+		"addresses than the aezeed, requires --i-understand-the-risk")
+
+	// shachainRoot signals that the shachain root of a channel should be
+	// derived. As the secrets derived from it allow the channel peer to
+	// claim all channel funds if we broadcast a revoked state, it also
+	// requires riskConfirmed to be set.
+	shachainRoot = flag.Bool("shachain-root", false, "only derive and "+
+		"print the root of the shachain that lnd derives the "+
+		"per-commitment secrets of the channel at --shachain-index "+
+		"from, requires --i-understand-the-risk")
+
+	// shachainIndex is the index of the channel's key in the revocation
+	// root key family.
+	shachainIndex = flag.Uint("shachain-index", 0, "the index of the "+
+		"revocation root key of the channel whose shachain root is "+
+		"derived with --shachain-root, each channel uses the next one")
+
+	// commitHeight is the commitment height that the per-commitment
+	// secret and point should be derived for.
+	commitHeight = flag.Uint64("commit-height", 0, "if set, also print "+
+		"the per-commitment secret and point at this commitment "+
+		"height, requires --shachain-root")
+
+	// remotePubkey is the multisig key of a channel peer, which the
+	// funding addresses of channels with it are derived with.
+	remotePubkey = flag.String("remote-pubkey", "", "only derive and "+
+		"print the P2WSH address of the 2-of-2 funding output that "+
+		"each of the first --funding-count multisig keys of the "+
+		"seed forms with this hex-encoded remote multisig key")
+
+	// fundingCount is the number of multisig keys that funding addresses
+	// are derived for.
+	fundingCount = flag.Uint("funding-count", 100, "the number of "+
+		"multisig keys to derive funding addresses for with "+
+		"--remote-pubkey, at most 100000")
+
+	// multisig is the M-of-N policy of a P2WSH multisig wallet that the
+	// seed is one of the cosigners of.
+	multisig = flag.String("multisig", "", "only derive and print the "+
+		"BIP48 account xpub of the seed, along with the descriptors "+
+		"and --count addresses of the sorted P2WSH multisig wallet "+
+		"with this M-of-N policy, whose other N-1 keys are given with "+
+		"--cosigner")
+
+	// cosigners holds the account xpubs of the other cosigners of the
+	// multisig wallet, collected from each --cosigner flag.
+	cosigners stringList
+
+	// verbosity is the level of detail the derivation steps are logged
+	// with to stderr, raised by each --verbose or -v flag.
+	verbosity verbosityFlag
+
+	// vanity is a substring that the searched for address should
+	// contain.
+	vanity = flag.String("vanity", "", "only search the external "+
+		"branch of --vanity-addr-type for the first address that "+
+		"contains this substring, ignoring case, and print it along "+
+		"with its path")
+
+	// vanityAddrType is the type of the address searched for with
+	// --vanity.
+	vanityAddrType = flag.String("vanity-addr-type", "p2wkh", "the type "+
+		"of the address searched for with --vanity (p2wkh, p2tr)")
+
+	// vanityMax is the maximum number of indexes searched with --vanity.
+	vanityMax = flag.Uint("vanity-max", 100000, "the maximum number of "+
+		"indexes searched with --vanity")
+
+	// bip85Index is the index of the BIP0085 child secret that should be
+	// derived from the seed. As the child secret gives full control over
+	// the funds it's used for, it also requires riskConfirmed to be set.
+	bip85Index = flag.Uint("bip85-index", 0, "if set, only derive and "+
+		"print the BIP85 child secret at this index, requires "+
+		"--i-understand-the-risk")
+
+	// bip85App is the BIP0085 application of the child secret.
+	bip85App = flag.String("bip85-app", aezeedcheck.BIP85AppBIP39, "the "+
+		"BIP85 application of the child secret derived with "+
+		"--bip85-index (bip39, hex, wif)")
+
+	// bip85Words is the number of words of a BIP0085 child mnemonic.
+	bip85Words = flag.Uint("bip85-words", 12, "the number of words (12, "+
+		"18, 24) of the BIP39 mnemonic derived with --bip85-index")
+
+	// bip85Bytes is the number of bytes of BIP0085 child entropy derived
+	// by the hex application.
+	bip85Bytes = flag.Uint("bip85-bytes", 32, "the number of bytes "+
+		"(16-64) of the entropy derived with --bip85-index when "+
+		"using --bip85-app=hex")
+
+	// derivePath is a custom BIP0032 derivation path that the single key
+	// should be derived at, instead of any of the fixed paths.
+	derivePath = flag.String("path", "", "only derive and print the "+
+		"public key and address at this derivation path, e.g. "+
+		"m/48'/0'/0'/2'/0/0, with hardened elements marked by ' or h")
+
+	// pathAddrType is the type of the address derived at derivePath.
+	pathAddrType = flag.String("addr-type", "p2wkh", "the type of the "+
+		"address derived with --path (p2wkh, np2wkh, p2tr, p2pkh)")
+
+	// outPath is the path of the file the results are written to, instead
+	// of stdout.
+	outPath = flag.String("out", "", "write the results to this file, "+
+		"only readable by the current user, instead of stdout")
+
+	// qr signals that the derived addresses, along with any xpubs and
+	// descriptors, should also be printed as QR codes.
+	qr = flag.Bool("qr", false, "also print each derived address, and "+
+		"any account xpub and descriptor, as a QR code in the "+
+		"terminal")
+
+	// qrDir is the directory QR code PNG files of the derived addresses and
+	// xpubs are written into.
+	qrDir = flag.String("qr-dir", "", "write a QR code PNG file of each "+
+		"derived address, and of each account xpub if --xpub is set, "+
+		"into this directory, named after its derivation path")
+
+	// esplora is the base URL of an Esplora server to look up the balance
+	// of each derived address from. Unless it's set, the tool never makes
+	// any network requests.
+	esplora = flag.String("esplora", "", "look up the confirmed balance "+
+		"of each derived address from the Esplora server with this "+
+		"base URL, e.g. https://blockstream.info/api, which reveals "+
+		"the addresses to the server")
+
+	// proxy is the URL of a SOCKS5 proxy that requests to the Esplora
+	// server are routed through.
+	proxy = flag.String("proxy", "", "route all requests to the "+
+		"--esplora server through this SOCKS5 proxy, e.g. "+
+		"socks5://127.0.0.1:9050 for Tor")
+
+	// scan signals that the used addresses should be found by scanning
+	// each branch with the Esplora server, instead of deriving a fixed
+	// number of them.
+	scan = flag.Bool("scan", false, "instead of deriving --count "+
+		"addresses, look up addresses on each branch from the "+
+		"--esplora server until --gap-limit consecutive unused ones "+
+		"are found, and print only the used ones")
+
+	// esploraTimeout is the timeout of each request to the Esplora
+	// server.
+	esploraTimeout = flag.Duration("timeout",
+		aezeedcheck.DefaultEsploraTimeout, "the timeout of each "+
+			"request to the --esplora server")
+
+	// selfTest signals that the derivation should be checked against the
+	// built-in test vector, instead of recovering a seed.
+	selfTest = flag.Bool("self-test", false, "only check that the "+
+		"built-in test vector derives the expected node pubkey and "+
+		"addresses, exiting with a non-zero status if it doesn't")
+
+	// pprofAddr is the local address to serve the profiles of
+	// net/http/pprof at. It's meant for performance work only, so it's
+	// left out of the usage message.
+	pprofAddr = flag.String("pprof", "", "serve the profiles of "+
+		"net/http/pprof at this localhost address, e.g. "+
+		"localhost:6060")
+
+	// riskConfirmed is the confirmation required before any private key
+	// material is printed.
+	riskConfirmed = flag.Bool("i-understand-the-risk", false, "confirm "+
+		"that private key material may be printed")
+)
+
+func main() {
+	flag.Var(&slip39Shares, "slip39-shares", "create an aezeed, "+
+		"enciphered with --pass, from the entropy reconstructed from "+
+		"this SLIP39 mnemonic share and print its mnemonic along "+
+		"with the usual output, must be given once for each share "+
+		"or as - to read the shares from stdin, one per line")
+	flag.Var(&verbosity, "verbose", "log each derivation step to "+
+		"stderr, may be given twice to also log each child key and "+
+		"the fingerprint at each depth, never logs secret key "+
+		"material")
+	flag.Var(&verbosity, "v", "shorthand for --verbose")
+	flag.Var(&cosigners, "cosigner", "the account xpub of one of the "+
+		"other cosigners of the --multisig wallet, may be given "+
+		"multiple times")
+	flag.Usage = usage
+	flag.Parse()
+
+	if err := run(os.Stdout); err != nil {
+		log.Print(err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// run deciphers the seed selected by the command line flags, and prints
+// everything derived from it to w. Any error is returned, leaving it up to
+// main to decide how to exit.
+func run(w io.Writer) error {
+	if err := setupColor(*colorMode); err != nil {
+		return err
+	}
+	if *pprofAddr != "" {
+		if err := startPprof(*pprofAddr); err != nil {
+			return err
+		}
+	}
+
+	var numSources int
+	for _, set := range []bool{
+		*mnemonic != "", *readStdin, *mnemonicFile != "",
+		*mnemonicEnv != "", *generate, *entropyHex != "",
+		len(slip39Shares) > 0, *watchXpub != "", *batchFile != "",
+	} {
+		if set {
+			numSources++
+		}
+	}
+	if numSources > 1 {
+		return errors.New("only one of --mnemonic, --stdin, " +
+			"--mnemonic-file, --mnemonic-env, --generate, " +
+			"--entropy, --slip39-shares, --watch-xpub and " +
+			"--batch-file can be used")
+	}
+
+	// Each of --generate, --entropy and --slip39-shares creates a new
+	// seed, rather than deciphering an existing one.
+	createSeed := creatingSeed()
+	if err := checkModes(); err != nil {
+		return err
+	}
+
+	// Verifying a signature doesn't require the seed at all, so we'll
+	// handle it before anything else.
+	if (*verifyAddr != "" || verifyingNodeMessage()) && numSources > 0 {
+		return errors.New("a signature can't be verified with a " +
+			"mnemonic")
+	}
+	if *verifyAddr != "" {
+		return verifyAddrMessage(w)
+	}
+	if verifyingNodeMessage() {
+		return verifyNodeMessage(w)
+	}
+
+	// The self-test brings its own seed, so there's nothing to read
+	// either.
+	if *selfTest {
+		if numSources > 0 {
+			return errors.New("--self-test can't be used with a " +
+				"mnemonic")
+		}
+
+		if err := aezeedcheck.SelfTest(); err != nil {
+			return withExitCode(exitDerive, err)
+		}
+
+		fmt.Fprintln(w, "self-test passed: the built-in test vector "+
+			"derives the expected node pubkey and addresses")
+		return nil
+	}
+
+	// If no source for the mnemonic was given, then we'll securely
+	// prompt for it, as long as there's a terminal to prompt on.
+	if *dryRun && numSources > 0 {
+		return errors.New("--dry-run can't be used with a mnemonic")
+	}
+	interactive := numSources == 0 && !*dryRun
+	if interactive && !stdinIsTerminal() {
+		flag.Usage()
+		return errors.New("no mnemonic given, and there's no " +
+			"terminal to prompt for it on")
+	}
+
+	if *recoverWord > aezeed.NummnemonicWords {
+		return fmt.Errorf("--recover-word must be between 1 and %v",
+			aezeed.NummnemonicWords)
+	}
+	if *passList != "" && (*aezeedPass != "" || *passEnv != "" ||
+		*recoverWord > 0) {
+
+		return errors.New("--pass-list can't be used with --pass, " +
+			"--pass-env or --recover-word")
+	}
+	if *passEmptyFirst && *passList == "" {
+		return errors.New("--pass-empty-first can only be used with " +
+			"--pass-list")
+	}
+
+	// A passphrase from the environment takes precedence over --pass, so
+	// that a default can be overridden without touching the command line.
+	if *passEnv != "" {
+		if pass, ok := os.LookupEnv(*passEnv); ok {
+			*aezeedPass = pass
+		}
+	}
+	if *signAddrIndex >= hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("--sign-addr-index must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+
+	// Without an index, the message is signed with the node key, which
+	// has no address type to pick.
+	if isFlagSet("sign-addr-type") && !isFlagSet("sign-addr-index") {
+		return errors.New("--sign-addr-type can only be used with " +
+			"--sign-addr-index")
+	}
+	bip85 := isFlagSet("bip85-index")
+	if *bip85Index >= hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("--bip85-index must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+	if bip85 {
+		err := aezeedcheck.ValidateBIP85Child(*bip85App, bip85Length())
+		if err != nil {
+			return err
+		}
+	}
+	if *vanityMax == 0 || *vanityMax > hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("--vanity-max must be between 1 and %v",
+			hdkeychain.HardenedKeyStart)
+	}
+	if *shachainIndex >= hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("--shachain-index must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+	if *commitHeight > aezeedcheck.MaxCommitHeight {
+		return fmt.Errorf("--commit-height must be at most %v",
+			uint64(aezeedcheck.MaxCommitHeight))
+	}
+	var multisigRequired int
+	if *multisig != "" {
+		var (
+			numKeys int
+			err     error
+		)
+		multisigRequired, numKeys, err = parseMultisig(*multisig)
+		if err != nil {
+			return fmt.Errorf("invalid --multisig: %w", err)
+		}
+		if len(cosigners) != numKeys-1 {
+			return fmt.Errorf("--multisig %v requires %v "+
+				"--cosigner xpub(s), instead got %v", *multisig,
+				numKeys-1, len(cosigners))
+		}
+	}
+	funding := *remotePubkey != ""
+	if *fundingCount == 0 ||
+		*fundingCount > aezeedcheck.MaxFundingIndexes {
+
+		return fmt.Errorf("--funding-count must be between 1 and %v",
+			aezeedcheck.MaxFundingIndexes)
+	}
+	var slip39Threshold, slip39NumShares int
+	if *slip39Split != "" {
+		var err error
+		slip39Threshold, slip39NumShares, err = parseSLIP39Split(
+			*slip39Split,
+		)
+		if err != nil {
+			return fmt.Errorf("invalid --slip39-split: %w", err)
+		}
+	}
+	prove := *challenge != ""
+	if *proveAddrIndex >= hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("--prove-addr-index must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+	if *fingerprintOnly && *format != aezeedcheck.FormatText &&
+		*format != aezeedcheck.FormatJSON {
+
+		return errors.New("--fingerprint can only be used with the " +
+			"text or json format")
+	}
+	if *batchFile != "" && *format != aezeedcheck.FormatText &&
+		*format != aezeedcheck.FormatTable &&
+		*format != aezeedcheck.FormatJSON {
+
+		return errors.New("--batch-file can only be used with the " +
+			"text, table or json format")
+	}
+	compare := *compareMnemonic != ""
+	if compare && *compareMnemonic == mnemonicStdin &&
+		(*readStdin || *mnemonic == mnemonicStdin) {
+
+		return errors.New("only one of the mnemonics can be read " +
+			"from stdin")
+	}
+	var pathChildNums []uint32
+	if *derivePath != "" {
+		var err error
+		pathChildNums, err = aezeedcheck.ParseDerivationPath(
+			*derivePath,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	// The config only holds 32-bit counts, so larger ones are rejected
+	// before they can wrap around.
+	if *count > aezeedcheck.MaxAddrs || *gapLimit > aezeedcheck.MaxAddrs {
+		return fmt.Errorf("--count and --gap-limit must be at most %v",
+			aezeedcheck.MaxAddrs)
+	}
+
+	// With the flags that relate to the seed itself checked, the
+	// remaining ones make up the config of the recovery.
+	cfg := aezeedcheck.Config{
+		WatchXpub:          *watchXpub,
+		WatchAddrType:      *watchAddrType,
+		Coin:               *coin,
+		Network:            *network,
+		SignetHRP:          *signetHRP,
+		Format:             *format,
+		BirthdayFormat:     *birthdayFormat,
+		MnemonicLines:      *printMnemonic,
+		CSVComments:        *csvComments,
+		Count:              uint32(*count),
+		Branch:             *branch,
+		BulkImport:         isFlagSet("gap-limit") && !*scan,
+		GapLimit:           uint32(*gapLimit),
+		LegacyUncompressed: *legacyUncompressed,
+		Uncompressed:       *uncompressed,
+		Scripts:            *scripts,
+		KeyFamilies:        *keyFamilies,
+		KeyLocatorPriv:     *keyPrivKey,
+		TowerSessions:      uint32(*towerSessions),
+		Xpub:               *xpub,
+		Slip132:            *slip132,
+		Descriptors:        *descriptors,
+		WIF:                *wif,
+		ShowEntropy:        *showEntropy,
+		BIP39:              *bip39,
+		Xprv:               *xprv,
+		SCBDir:             *scbDir,
+		QR:                 *qr,
+		QRDir:              *qrDir,
+		Esplora:            *esplora,
+		EsploraTimeout:     *esploraTimeout,
+		Proxy:              *proxy,
+		Scan:               *scan,
+		Verbosity:          uint8(verbosity),
+		Progress:           newProgressIndicator().update,
+		Warn:               func(msg string) { warnf("%v", msg) },
+		StrictBirthday:     *strictBirthday,
+	}
+	if *minBirthdayStr != "" {
+		var err error
+		cfg.MinBirthday, err = parseBirthday(*minBirthdayStr)
+		if err != nil {
+			return fmt.Errorf("invalid --min-birthday: %w", err)
+		}
+	}
+	if isFlagSet("addr-types") {
+		for _, name := range strings.Split(*addrTypesList, ",") {
+			cfg.AddrTypes = append(
+				cfg.AddrTypes, strings.TrimSpace(name),
+			)
+		}
+	}
+	if *indexRange != "" {
+		if isFlagSet("count") {
+			return errors.New("--range can't be used with --count")
+		}
+
+		first, last, err := parseIndexRange(*indexRange)
+		if err != nil {
+			return err
+		}
+		cfg.FirstIndex = first
+		cfg.Count = last - first + 1
+	}
+	if *accounts != "" {
+		accountRange, err := parseAccountRange(*accounts)
+		if err != nil {
+			return err
+		}
+		cfg.Accounts = accountRange
+	}
+	if isFlagSet("coin-type") {
+		if *coinType > math.MaxUint32 {
+			return errors.New("--coin-type must fit in 32 bits")
+		}
+
+		customCoinType := uint32(*coinType)
+		cfg.CoinType = &customCoinType
+	}
+	if *towerSessions > math.MaxUint32 {
+		return errors.New("--tower-sessions must fit in 32 bits")
+	}
+	if isFlagSet("key-family") {
+		if *keyFamily > math.MaxUint32 || *keyIndex > math.MaxUint32 {
+			return errors.New("--key-family and --key-index must " +
+				"fit in 32 bits")
+		}
+
+		cfg.KeyLocator = &keychain.KeyLocator{
+			Family: keychain.KeyFamily(*keyFamily),
+			Index:  uint32(*keyIndex),
+		}
+	} else if isFlagSet("key-index") {
+		return errors.New("--key-index can only be used with " +
+			"--key-family")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Planning the paths doesn't require the seed, so there's nothing
+	// more to read.
+	if *dryRun {
+		return printDryRun(w, &cfg)
+	}
+
+	// In watch-only mode, there's no seed to read, so we'll derive the
+	// addresses right away.
+	if *watchXpub != "" {
+		return writeResults(w, cfg, exitUsage)
+	}
+
+	// We'll decode the expected node key right away, so a malformed key
+	// is reported before the user is prompted for anything.
+	var remoteMultiSigPub []byte
+	if funding {
+		var err error
+		remoteMultiSigPub, err = parseNodePub(*remotePubkey)
+		if err != nil {
+			return fmt.Errorf("invalid --remote-pubkey: %w", err)
+		}
+	}
+
+	var ecdhPeerPub []byte
+	if *ecdhPeer != "" {
+		var err error
+		ecdhPeerPub, err = parseNodePub(*ecdhPeer)
+		if err != nil {
+			return fmt.Errorf("invalid --ecdh: %w", err)
+		}
+	}
+
+	var targetAddr btcutil.Address
+	if *containsAddr != "" {
+		var err error
+		targetAddr, err = parseTargetAddr(&cfg, *containsAddr)
+		if err != nil {
+			return fmt.Errorf("invalid --contains-address: %w", err)
+		}
+	}
+
+	var expectedNodePub []byte
+	if *expectNodePub != "" {
+		var err error
+		expectedNodePub, err = parseNodePub(*expectNodePub)
+		if err != nil {
+			return fmt.Errorf("invalid --expect-node-pubkey: %w",
+				err)
+		}
+	}
+
+	if *scbFile != "" {
+		var err error
+		cfg.SCB, err = ioutil.ReadFile(*scbFile)
+		if err != nil {
+			return fmt.Errorf("unable to read channel backup: %w",
+				err)
+		}
+	}
+
+	// We'll never print private key material unless the user explicitly
+	// acknowledged the risk of doing so.
+	if *xprv {
+		if !*riskConfirmed {
+			return errors.New("refusing to print extended " +
+				"private keys without --i-understand-the-risk")
+		}
+
+		warnf("the account extended private keys printed below give " +
+			"full control over all funds of the accounts, never " +
+			"share them!")
+	}
+	if *wif {
+		if !*riskConfirmed {
+			return errors.New("refusing to print WIF private " +
+				"keys without --i-understand-the-risk")
+		}
+
+		warnf("the WIF private keys printed below give full control " +
+			"over the funds of their addresses, never share them!")
+	}
+	if *showEntropy || *bip39 {
+		if !*riskConfirmed {
+			return errors.New("refusing to print the seed " +
+				"entropy without --i-understand-the-risk")
+		}
+
+		warnf("the seed entropy printed below gives full control " +
+			"over all funds, never share it!")
+	}
+	if *bip39 {
+		notef("the BIP39 mnemonic stretches its words into a " +
+			"different root key, so it derives different keys " +
+			"and addresses than the aezeed!")
+	}
+	if bip85 {
+		if !*riskConfirmed {
+			return errors.New("refusing to print a BIP85 child " +
+				"secret without --i-understand-the-risk")
+		}
+
+		warnf("the BIP85 child secret printed below gives full " +
+			"control over all funds it's used for, never share " +
+			"it!")
+	}
+	if *shachainRoot {
+		if !*riskConfirmed {
+			return errors.New("refusing to print a shachain " +
+				"root without --i-understand-the-risk")
+		}
+
+		warnf("the shachain secrets printed below let the channel " +
+			"peer claim all channel funds if a revoked state is " +
+			"ever broadcast, never share them!")
+	}
+	if *ecdhPeer != "" {
+		if !*riskConfirmed {
+			return errors.New("refusing to print an ECDH shared " +
+				"secret without --i-understand-the-risk")
+		}
+
+		warnf("the ECDH shared secret printed below is derived from " +
+			"the node private key, never share it!")
+	}
+	if *slip39Split != "" {
+		if !*riskConfirmed {
+			return errors.New("refusing to print SLIP39 shares " +
+				"without --i-understand-the-risk")
+		}
+
+		warnf("any threshold of the SLIP39 shares printed below " +
+			"gives full control over all funds, store them apart " +
+			"and never share them!")
+	}
+	if *keyPrivKey {
+		if !*riskConfirmed {
+			return errors.New("refusing to print private key " +
+				"without --i-understand-the-risk")
+		}
+
+		warnf("the private key printed below may give control over " +
+			"channel funds, never share it!")
+	}
+
+	// Each mnemonic of a batch file brings its own passphrase, so there's
+	// nothing more to read.
+	if *batchFile != "" {
+		return runBatch(w, cfg)
+	}
+
+	// If we only need to verify the words, then we can do so without the
+	// passphrase, and we're done.
+	if *verifyWords {
+		words, rawMnemonic, err := readMnemonicPhrase()
+		if err != nil {
+			return fmt.Errorf("words are invalid: %w", err)
+		}
+		err = aezeedcheck.VerifyChecksum(words)
+		aezeedcheck.ZeroBytes(rawMnemonic)
+		if err != nil {
+			return fmt.Errorf("words are invalid: %w", err)
+		}
+
+		fmt.Fprintln(w, "words are valid: the mnemonic checksum is "+
+			"correct, so if deciphering fails, the passphrase is "+
+			"wrong")
+		return nil
+	}
+
+	// Reformatting the mnemonic only requires its words to be valid, so
+	// there's no need for the passphrase either.
+	if *printMnemonic && !createSeed && !isFlagSet("new-pass") {
+		return printNormalizedMnemonic(w)
+	}
+
+	// Unless we're creating a new seed, we'll read the user's mnemonic,
+	// along with its passphrase.
+	var err error
+	if createSeed {
+		birthday := time.Now()
+		if *birthdayStr != "" {
+			birthday, err = parseBirthday(*birthdayStr)
+			if err != nil {
+				return fmt.Errorf("invalid birthday: %w", err)
+			}
+		}
+
+		var cipherSeed *aezeed.CipherSeed
+		if len(slip39Shares) > 0 {
+			cipherSeed, err = newSLIP39Seed(birthday)
+		} else {
+			cipherSeed, err = newSeed(*entropyHex, birthday)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to create seed: %w", err)
+		}
+		defer aezeedcheck.ZeroCipherSeed(cipherSeed)
+
+		cfg.Passphrase = []byte(*aezeedPass)
+		cfg.Mnemonic, err = cipherSeed.ToMnemonic(cfg.Passphrase)
+		if err != nil {
+			aezeedcheck.ZeroBytes(cfg.Passphrase)
+			return fmt.Errorf("unable to encipher seed: %w", err)
+		}
+		cfg.ShowMnemonic = true
+	} else {
+		cfg.Mnemonic, cfg.Passphrase, err = readSeed(interactive)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Once we're done, we no longer need the passphrase, so we'll zero
+	// it.
+	defer aezeedcheck.ZeroBytes(cfg.Passphrase)
+
+	// Re-enciphering, checking, comparing and signing with the seed, as
+	// well as deriving BIP85 children, custom paths, vanity addresses,
+	// shachain roots, funding addresses, multisig wallets, SLIP39 shares,
+	// ownership proofs, the fingerprint, ECDH shared secrets or searching
+	// for an address, only need the deciphered seed itself, so we'll
+	// handle them right here.
+	if isFlagSet("new-pass") || *checkOnly || expectedNodePub != nil ||
+		*signMessage != "" || bip85 || pathChildNums != nil ||
+		compare || *vanity != "" || *shachainRoot || funding ||
+		*multisig != "" || *slip39Split != "" || prove ||
+		*fingerprintOnly || ecdhPeerPub != nil || targetAddr != nil {
+
+		cipherSeed, err := cfg.Mnemonic.ToCipherSeed(cfg.Passphrase)
+		aezeedcheck.ZeroBytes(cfg.Passphrase)
+		if err != nil {
+			return fmt.Errorf("unable to decrypt cipher seed: %w",
+				err)
+		}
+		defer aezeedcheck.ZeroCipherSeed(cipherSeed)
+
+		err = cfg.CheckBirthday(cipherSeed.BirthdayTime())
+		if err != nil {
+			return err
+		}
+
+		if expectedNodePub != nil {
+			return checkNodePub(
+				w, &cfg, cipherSeed, expectedNodePub,
+			)
+		}
+		if *signMessage != "" && isFlagSet("sign-addr-index") {
+			return signAddrMessage(w, &cfg, cipherSeed)
+		}
+		if *signMessage != "" {
+			return signNodeMessage(w, &cfg, cipherSeed)
+		}
+		if bip85 {
+			return printBIP85Child(w, &cfg, cipherSeed)
+		}
+		if pathChildNums != nil {
+			return printPathAddr(
+				w, &cfg, cipherSeed, pathChildNums,
+			)
+		}
+		if compare {
+			return compareSeeds(w, &cfg, cipherSeed)
+		}
+		if *vanity != "" {
+			return printVanityAddr(w, &cfg, cipherSeed)
+		}
+		if *shachainRoot {
+			return printShachain(w, &cfg, cipherSeed)
+		}
+		if funding {
+			return printFundingAddrs(
+				w, &cfg, cipherSeed, remoteMultiSigPub,
+			)
+		}
+		if *multisig != "" {
+			return printMultisig(
+				w, &cfg, cipherSeed, multisigRequired,
+			)
+		}
+		if *slip39Split != "" {
+			return printSLIP39Shares(
+				w, cipherSeed, slip39Threshold, slip39NumShares,
+			)
+		}
+		if prove {
+			return proveAddrOwnership(w, &cfg, cipherSeed)
+		}
+		if *fingerprintOnly {
+			return printFingerprint(w, &cfg, cipherSeed)
+		}
+		if ecdhPeerPub != nil {
+			return printECDH(w, &cfg, cipherSeed, ecdhPeerPub)
+		}
+		if targetAddr != nil {
+			return printContainsAddr(
+				w, &cfg, cipherSeed, targetAddr,
+			)
+		}
+
+		return checkOrChangePass(w, &cfg.Mnemonic, cipherSeed)
+	}
+
+	// There's no place for the mnemonic of a newly generated seed in the
+	// CSV, bitcoind, electrum, sparrow or coldcard output, so we'll print
+	// it to stderr instead.
+	if cfg.ShowMnemonic && (cfg.Format == aezeedcheck.FormatCSV ||
+		cfg.Format == aezeedcheck.FormatBitcoind ||
+		cfg.Format == aezeedcheck.FormatElectrum ||
+		cfg.Format == aezeedcheck.FormatSparrow ||
+		cfg.Format == aezeedcheck.FormatColdcard) {
+
+		printNewMnemonic(os.Stderr, cfg.Mnemonic)
+	}
+
+	// The config was already validated above, and failures to decipher
+	// the seed are detected by their own exit code, so any error left is
+	// due to the derivation itself.
+	return writeResults(w, cfg, exitDerive)
+}
+
+// printFingerprint prints the master fingerprint and node pubkey of the passed
+// cipher seed in the configured format.
+func printFingerprint(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	id, err := aezeedcheck.IdentifySeed(cipherSeed, params)
+	if err != nil {
+		return withExitCode(exitDerive, err)
+	}
+
+	return aezeedcheck.PrintSeedIdentity(w, id, cfg.Format)
+}
+
+// checkNodePub checks that the passed cipher seed derives the expected node
+// identity key on the configured network, returning an error if it doesn't.
+func checkNodePub(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed, expected []byte) error {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	nodePub, err := aezeedcheck.DeriveNodeKey(cipherSeed, params)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"node key: %w", err))
+	}
+
+	if !aezeedcheck.NodeKeyMatches(nodePub, expected) {
+		return withExitCode(exitDerive, fmt.Errorf("node pubkey "+
+			"mismatch: the seed derives %x on %v, expected %x",
+			nodePub.SerializeCompressed(), cfg.Network, expected))
+	}
+
+	fmt.Fprintf(w, "node pubkey matches: %x\n", expected)
+
+	return nil
+}
+
+// checkOrChangePass handles the modes that only need the deciphered seed of
+// the passed mnemonic. If a new passphrase was given, then the seed is
+// re-enciphered with it, and the resulting mnemonic is printed. Otherwise, the
+// seed is reported as valid.
+func checkOrChangePass(w io.Writer, m *aezeed.Mnemonic,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	// The new passphrase may be empty, in which case the default
+	// passphrase is used, just like for the old one.
+	if isFlagSet("new-pass") {
+		newPassword := []byte(*newAezeedPass)
+		changedPhrase, err := aezeedcheck.ChangePass(
+			cipherSeed, newPassword,
+		)
+		aezeedcheck.ZeroBytes(newPassword)
+		if err != nil {
+			return fmt.Errorf("unable to change passphrase: %w",
+				err)
+		}
+
+		warnf("the old mnemonic is now retired, make sure to " +
+			"securely destroy every copy of it once the new " +
+			"mnemonic below is written down!")
+		printNewMnemonic(w, changedPhrase)
+		return nil
+	}
+
+	fmt.Fprintf(w, "valid\nMnemonic Version: %v\nWallet Birthday: %v, "+
+		"Internal Version: %v\n", aezeedcheck.MnemonicVersion(m[:]),
+		aezeedcheck.FormatBirthday(
+			cipherSeed.BirthdayTime(), *birthdayFormat,
+		), cipherSeed.InternalVersion)
+
+	return nil
+}
+
+// parseAccountRange parses an account index of the form N, or an inclusive
+// range of account indexes of the form N-M, returning each index in turn. The
+// accounts must be below the hardened range, and at most MaxAccounts of them
+// can be given.
+func parseAccountRange(s string) ([]uint32, error) {
+	parts := strings.SplitN(s, "-", 2)
+	first, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account %q: %w", parts[0], err)
+	}
+	last := first
+	if len(parts) == 2 {
+		last, err = strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account %q: %w",
+				parts[1], err)
+		}
+	}
+	if last < first {
+		return nil, fmt.Errorf("expected an account range of the "+
+			"form N-M with N <= M, instead got %q", s)
+	}
+	if last >= hdkeychain.HardenedKeyStart {
+		return nil, fmt.Errorf("account must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+	if last-first >= aezeedcheck.MaxAccounts {
+		return nil, fmt.Errorf("at most %v accounts can be derived "+
+			"at once, instead got %q", aezeedcheck.MaxAccounts, s)
+	}
+
+	accounts := make([]uint32, 0, last-first+1)
+	for account := first; account <= last; account++ {
+		accounts = append(accounts, uint32(account))
+	}
+
+	return accounts, nil
+}
+
+// parseIndexRange parses an inclusive range of address indexes of the form
+// START:END, both of which must be below the hardened range, spanning at most
+// MaxAddrs indexes.
+func parseIndexRange(s string) (uint32, uint32, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected a range of the form "+
+			"START:END, instead got %q", s)
+	}
+
+	first, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range start: %w", err)
+	}
+	last, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range end: %w", err)
+	}
+	if first > last || last >= hdkeychain.HardenedKeyStart {
+		return 0, 0, fmt.Errorf("expected START <= END < %v, "+
+			"instead got %q", hdkeychain.HardenedKeyStart, s)
+	}
+	if last-first >= aezeedcheck.MaxAddrs {
+		return 0, 0, fmt.Errorf("at most %v addresses can be "+
+			"derived on each branch, instead got %q",
+			aezeedcheck.MaxAddrs, s)
+	}
+
+	return uint32(first), uint32(last), nil
+}
+
+// isFlagSet returns true if the flag with the given name was explicitly set
+// on the command line.
+func isFlagSet(name string) bool {
+	var set bool
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/btcsuite/golangcrypto/ssh/terminal"
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// mnemonicStdin is the value of the --mnemonic flag that signals the mnemonic
+// should be read from stdin instead.
+const mnemonicStdin = "-"
+
+// readSeed reads the mnemonic from the source selected on the command line,
+// along with its passphrase, prompting for the passphrase if interactive is
+// true and none was given. If a list of candidate passphrases was given, then
+// the one that deciphers the seed is returned instead. The passphrase should
+// be zeroed by the caller once it's no longer needed.
+func readSeed(interactive bool) (aezeed.Mnemonic, []byte, error) {
+	var aezeedPhrase aezeed.Mnemonic
+
+	mnemonicPhrase, rawMnemonic, err := readMnemonicPhrase()
+	if err != nil {
+		return aezeedPhrase, nil, err
+	}
+	defer aezeedcheck.ZeroBytes(rawMnemonic)
+
+	copy(aezeedPhrase[:], mnemonicPhrase)
+
+	var password []byte
+	switch {
+	// If we're searching a list of candidate passphrases, then there's
+	// no passphrase to read.
+	case *passList != "":
+
+	case *aezeedPass != "":
+		password = []byte(*aezeedPass)
+
+	case interactive:
+		password, err = promptPassphrase()
+		if err != nil {
+			return aezeedPhrase, nil, fmt.Errorf("unable to read "+
+				"passphrase: %w", err)
+		}
+	}
+
+	// If one of the words was forgotten, then we'll need to recover it
+	// before the seed can be deciphered.
+	if *recoverWord > 0 {
+		pos := int(*recoverWord) - 1
+		word, err := recoverMissingWord(&aezeedPhrase, pos, password)
+		if err != nil {
+			aezeedcheck.ZeroBytes(password)
+			return aezeedPhrase, nil, fmt.Errorf("unable to "+
+				"recover word: %w", err)
+		}
+		aezeedPhrase[pos] = word
+	}
+
+	// Before deciphering, we'll decode the version of the mnemonic, so
+	// the user can tell a version mismatch apart from a bad passphrase.
+	version := aezeedcheck.MnemonicVersion(aezeedPhrase[:])
+	if version != aezeed.CipherSeedVersion {
+		warnf("mnemonic version %v is not supported, only version "+
+			"%v mnemonics can be deciphered", version,
+			aezeed.CipherSeedVersion)
+	}
+
+	if *passList != "" {
+		password, err = findPassphrase(
+			&aezeedPhrase, *passList, *passEmptyFirst,
+		)
+		if err != nil {
+			return aezeedPhrase, nil, fmt.Errorf("unable to find "+
+				"passphrase: %w", err)
+		}
+	}
+
+	return aezeedPhrase, password, nil
+}
+
+// errBIP39Seed is returned for a mnemonic that looks like a BIP0039 seed. Both
+// schemes share the same word list, so it's an easy mistake to make.
+var errBIP39Seed = errors.New("this looks like a BIP39 seed, not an aezeed: " +
+	"its words match the checksum of BIP39 rather than the one of " +
+	"aezeed, so it has to be recovered with a BIP39 wallet instead")
+
+// readMnemonicPhrase reads the mnemonic from the source selected on the
+// command line, and ensures it's made up of the expected number of valid words.
+// Along with the normalized words, the raw input they were parsed from is
+// returned, if any, which should be zeroed by the caller once it's no longer
+// needed.
+func readMnemonicPhrase() ([]string, []byte, error) {
+	mnemonicPhrase, rawMnemonic, err := readMnemonic()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read mnemonic: %w", err)
+	}
+
+	// Pasting a BIP0039 seed is a common mistake, which would otherwise
+	// only show up as a confusing wrong word count or checksum.
+	if aezeedcheck.LooksLikeBIP39(mnemonicPhrase) {
+		aezeedcheck.ZeroBytes(rawMnemonic)
+		return nil, nil, errBIP39Seed
+	}
+
+	if len(mnemonicPhrase) != aezeed.NummnemonicWords {
+		err := wordCountError(mnemonicPhrase)
+		aezeedcheck.ZeroBytes(rawMnemonic)
+		return nil, nil, err
+	}
+
+	// If a forgotten word is being recovered, then it must be marked with
+	// the placeholder. We'll temporarily fill in the first word of the
+	// word list, so the remaining words can be validated as usual.
+	if *recoverWord > 0 {
+		pos := int(*recoverWord) - 1
+		if mnemonicPhrase[pos] != unknownWord {
+			aezeedcheck.ZeroBytes(rawMnemonic)
+			return nil, nil, fmt.Errorf("word %v must be %q to be "+
+				"recovered", pos+1, unknownWord)
+		}
+		mnemonicPhrase[pos] = aezeedcheck.WordList[0]
+	}
+
+	if err := normalizeMnemonic(mnemonicPhrase); err != nil {
+		aezeedcheck.ZeroBytes(rawMnemonic)
+		return nil, nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	return mnemonicPhrase, rawMnemonic, nil
+}
+
+// wordCountError describes a mnemonic with the wrong number of words. To help
+// the user spot where the words went missing, or where extra ones crept in,
+// the first and last parsed words are included.
+func wordCountError(words []string) error {
+	if len(words) == 0 {
+		return fmt.Errorf("expected %v words, instead got none",
+			aezeed.NummnemonicWords)
+	}
+
+	hint := "check that no word was left out"
+	if len(words) > aezeed.NummnemonicWords {
+		hint = "check that no words were split or repeated, and that " +
+			"the input doesn't include word numbers"
+	}
+
+	return fmt.Errorf("expected %v words, instead got %v, from %q to %q: "+
+		"%v", aezeed.NummnemonicWords, len(words), words[0],
+		words[len(words)-1], hint)
+}
+
+// readMnemonic returns the words of the mnemonic from the source selected on
+// the command line, or prompts for it if no source was selected. Along with
+// the words, the raw input they were parsed from is returned, if any, which
+// should be zeroed by the caller once it's no longer needed.
+func readMnemonic() ([]string, []byte, error) {
+	switch {
+	case *mnemonicFile != "":
+		return readMnemonicFile(*mnemonicFile)
+
+	case *mnemonicEnv != "":
+		return readMnemonicEnv(*mnemonicEnv)
+
+	case *readStdin || *mnemonic == mnemonicStdin:
+		return readMnemonicWords(os.Stdin)
+
+	case *mnemonic == "":
+		return promptMnemonic()
+	}
+
+	// Passing the mnemonic itself as a flag leaks it into the shell
+	// history and process table, so we'll nudge the user towards one of
+	// the safer alternatives.
+	warnf("passing the mnemonic on the command line is deprecated, use " +
+		"--mnemonic=- or --stdin to read it from stdin instead")
+
+	// The words may be separated by spaces or new lines, so we'll split on
+	// any amount of whitespace.
+	return splitMnemonic(*mnemonic), nil, nil
+}
+
+// splitMnemonic splits the passed input into the words of a mnemonic, which
+// are separated by any amount of whitespace. When only reformatting it with
+// --print-mnemonic, any character that isn't a letter, like a comma or the
+// numbering of a written down mnemonic, separates the words as well.
+func splitMnemonic(s string) []string {
+	if !*printMnemonic || *recoverWord > 0 {
+		return strings.Fields(s)
+	}
+
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// readMnemonicWords reads the words of a mnemonic from the passed reader. The
+// words may be separated by any amount of whitespace, including new lines.
+func readMnemonicWords(r io.Reader) ([]string, []byte, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return splitMnemonic(string(content)), content, nil
+}
+
+// readMnemonicFile reads the words of a mnemonic from the file at the passed
+// path. To avoid leaking any part of the mnemonic, errors never include the
+// contents of the file. The number of words is left for readMnemonicPhrase to
+// check, so that a BIP39 seed is still detected as such.
+func readMnemonicFile(path string) ([]string, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	words, content, err := readMnemonicWords(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read %v: %w", path, err)
+	}
+
+	return words, content, nil
+}
+
+// readMnemonicEnv reads the words of a mnemonic from the environment variable
+// with the passed name. To avoid leaking any part of the mnemonic, errors
+// never include the value of the variable.
+func readMnemonicEnv(name string) ([]string, []byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("environment variable %v isn't "+
+			"set", name)
+	}
+
+	return splitMnemonic(value), nil, nil
+}
+
+// stdinIsTerminal returns true if stdin is attached to a terminal, meaning we
+// can interactively prompt the user.
+func stdinIsTerminal() bool {
+	return terminal.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// promptSecret prints the passed prompt to stderr, then reads a single line
+// from the terminal with echo disabled.
+//
+// NOTE: The terminal package is btcsuite's vendored fork of the one that
+// became golang.org/x/term, which has the same API. It's already a dependency
+// of btcwallet, so we use it instead of pulling in x/term and the newer
+// x/sys it requires.
+func promptSecret(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+
+	// As the new line the user typed wasn't echoed, we'll print one
+	// ourselves.
+	fmt.Fprintln(os.Stderr)
+
+	return secret, err
+}
+
+// promptMnemonic interactively reads the mnemonic from the terminal as a
+// single line, without echoing it.
+func promptMnemonic() ([]string, []byte, error) {
+	input, err := promptSecret("Input your 24-word mnemonic separated " +
+		"by spaces: ")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return splitMnemonic(string(input)), input, nil
+}
+
+// promptPassphrase interactively reads the passphrase of the aezeed from the
+// terminal, without echoing it. An empty passphrase is returned as nil.
+func promptPassphrase() ([]byte, error) {
+	pass, err := promptSecret("Input your cipher seed passphrase (press " +
+		"enter if your seed doesn't have a passphrase): ")
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, nil
+	}
+
+	return pass, nil
+}
+
+// normalizeMnemonic lowercases and trims each of the passed words in place, as
+// words pasted from password managers are often capitalized. Each word that
+// isn't part of the aezeed word list is reported on stderr, along with the
+// closest valid words, in which case an error is returned.
+func normalizeMnemonic(words []string) error {
+	var numUnknown int
+	for i, word := range words {
+		words[i] = strings.ToLower(strings.TrimSpace(word))
+		if aezeedcheck.IsWord(words[i]) {
+			continue
+		}
+		numUnknown++
+
+		msg := fmt.Sprintf("word %v %q not found", i+1, words[i])
+		suggestions := aezeedcheck.SuggestWords(words[i])
+		for j, suggestion := range suggestions {
+			switch {
+			case j == 0:
+				msg += "; did you mean "
+			case j == len(suggestions)-1:
+				msg += " or "
+			default:
+				msg += ", "
+			}
+			msg += strconv.Quote(suggestion)
+		}
+		if len(suggestions) > 0 {
+			msg += "?"
+		}
+		fmt.Fprintln(os.Stderr, msg)
+	}
+
+	if numUnknown > 0 {
+		return fmt.Errorf("%v word(s) not part of the aezeed word list",
+			numUnknown)
+	}
+
+	return nil
+}
+
+// newSeed creates a new cipher seed with the passed birthday from the given
+// hex-encoded entropy. If no entropy is given, then fresh entropy is read from
+// the system's secure random number generator instead.
+func newSeed(entropyHex string, birthday time.Time) (*aezeed.CipherSeed,
+	error) {
+
+	if entropyHex == "" {
+		var entropy [aezeed.EntropySize]byte
+		defer aezeedcheck.ZeroBytes(entropy[:])
+
+		if _, err := rand.Read(entropy[:]); err != nil {
+			return nil, err
+		}
+
+		return aezeed.New(aezeed.CipherSeedVersion, &entropy, birthday)
+	}
+
+	decoded, err := hex.DecodeString(entropyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entropy: %w", err)
+	}
+	defer aezeedcheck.ZeroBytes(decoded)
+
+	return newSeedFromEntropy(decoded, birthday)
+}
+
+// newSeedFromEntropy creates a new cipher seed with the passed birthday from
+// the given entropy, which must be exactly aezeed.EntropySize bytes long.
+func newSeedFromEntropy(entropy []byte,
+	birthday time.Time) (*aezeed.CipherSeed, error) {
+
+	if len(entropy) != aezeed.EntropySize {
+		return nil, fmt.Errorf("expected %v bytes of entropy, "+
+			"instead got %v", aezeed.EntropySize, len(entropy))
+	}
+
+	var seedEntropy [aezeed.EntropySize]byte
+	defer aezeedcheck.ZeroBytes(seedEntropy[:])
+	copy(seedEntropy[:], entropy)
+
+	return aezeed.New(aezeed.CipherSeedVersion, &seedEntropy, birthday)
+}
+
+// printNormalizedMnemonic reads the mnemonic, checks that each of its words is
+// part of the aezeed word list, and prints it with one numbered, lowercase word
+// per line. The seed isn't deciphered, so no passphrase is needed. As the
+// checksum doesn't require the passphrase either, a mismatch is reported as a
+// warning.
+func printNormalizedMnemonic(w io.Writer) error {
+	words, rawMnemonic, err := readMnemonicPhrase()
+	if err != nil {
+		return err
+	}
+	aezeedcheck.ZeroBytes(rawMnemonic)
+
+	if err := aezeedcheck.VerifyChecksum(words); err != nil {
+		warnf("the mnemonic checksum doesn't match, so some of its "+
+			"words may be mistyped: %v", err)
+	}
+
+	var m aezeed.Mnemonic
+	copy(m[:], words)
+	aezeedcheck.PrintMnemonicLines(w, m)
+
+	return nil
+}
+
+// printNewMnemonic prints the mnemonic of a newly created or re-enciphered
+// seed, with one numbered word per line if --print-mnemonic is set, or in the
+// columns of lncli otherwise.
+func printNewMnemonic(w io.Writer, m aezeed.Mnemonic) {
+	if *printMnemonic {
+		aezeedcheck.PrintMnemonicLines(w, m)
+		return
+	}
+
+	aezeedcheck.PrintMnemonic(w, m)
+}
+
+// parseBirthday parses the birthday of a seed, given either as a date in the
+// form YYYY-MM-DD or as an RFC3339 timestamp. As an aezeed counts the days of
+// its birthday since the genesis block in 16 bits, any other time would wrap
+// around, so it's rejected.
+func parseBirthday(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	days := aezeedcheck.BirthdayDays(t)
+	if days < 0 || days > math.MaxUint16 {
+		maxBirthday := aezeed.BitcoinGenesisDate.AddDate(
+			0, 0, math.MaxUint16,
+		)
+		return time.Time{}, fmt.Errorf("an aezeed can only encode "+
+			"birthdays from %v to %v, instead got %v",
+			aezeed.BitcoinGenesisDate.UTC().Format(time.RFC3339),
+			maxBirthday.UTC().Format(time.RFC3339), s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lightninglabs/aezeedcheck"
+)
+
+// TestSplitMnemonic asserts that the words of a mnemonic are split on any
+// kind and amount of whitespace, and on any character that isn't a letter
+// when only reformatting it with --print-mnemonic.
+func TestSplitMnemonic(t *testing.T) {
+	words := []string{"ability", "duty", "swarm", "cloth"}
+
+	tests := []struct {
+		name          string
+		input         string
+		printMnemonic bool
+		expected      []string
+	}{
+		{
+			name:     "spaces",
+			input:    "ability duty  swarm   cloth",
+			expected: words,
+		},
+		{
+			name:     "newlines",
+			input:    "ability\nduty\r\nswarm\ncloth\n",
+			expected: words,
+		},
+		{
+			name:     "mixed",
+			input:    "  ability\tduty\n \nswarm \t cloth ",
+			expected: words,
+		},
+		{
+			name:     "empty",
+			input:    " \n\t ",
+			expected: []string{},
+		},
+		{
+			name:     "numbering kept",
+			input:    "1. ability 2. duty",
+			expected: []string{"1.", "ability", "2.", "duty"},
+		},
+		{
+			name:          "numbering stripped",
+			input:         " 1. ability\n 2. duty,swarm;3.cloth",
+			printMnemonic: true,
+			expected:      words,
+		},
+	}
+
+	defer func(old bool) { *printMnemonic = old }(*printMnemonic)
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			*printMnemonic = test.printMnemonic
+
+			got := splitMnemonic(test.input)
+			if len(got) == 0 && len(test.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("expected %q, instead got %q",
+					test.expected, got)
+			}
+		})
+	}
+}
+
+// TestReadMnemonicFileBIP39 asserts that a 12-word BIP39 seed read with
+// --mnemonic-file is detected as such, rather than rejected for its number of
+// words.
+func TestReadMnemonicFileBIP39(t *testing.T) {
+	words, err := aezeedcheck.EntropyToBIP39(make([]byte, 16))
+	if err != nil {
+		t.Fatalf("unable to encode BIP39 mnemonic: %v", err)
+	}
+	if len(words) != 12 {
+		t.Fatalf("expected 12 words, instead got %v", len(words))
+	}
+
+	dir, err := ioutil.TempDir("", "aezeedcheck")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "mnemonic.txt")
+	content := []byte(strings.Join(words, " ") + "\n")
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("unable to write mnemonic file: %v", err)
+	}
+
+	defer func(old string) { *mnemonicFile = old }(*mnemonicFile)
+	*mnemonicFile = path
+
+	_, _, err = readMnemonicPhrase()
+	if !errors.Is(err, errBIP39Seed) {
+		t.Fatalf("expected %v, instead got %v", errBIP39Seed, err)
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fullRecovery stands for the default mode of the tool, a full recovery of an
+// existing seed, in the modes that an option can be used with.
+const fullRecovery = "a full recovery"
+
+// mode is one of the things the tool can do instead of a full recovery of an
+// existing seed. Only one mode can be selected at a time.
+type mode struct {
+	// flag is the flag that selects the mode.
+	flag string
+
+	// set returns true if the mode was selected on the command line.
+	set func() bool
+}
+
+// flagSet returns a function that reports whether the flag with the given
+// name was explicitly set on the command line.
+func flagSet(name string) func() bool {
+	return func() bool {
+		return isFlagSet(name)
+	}
+}
+
+// creatingSeed returns true if a new seed is created, rather than an existing
+// one deciphered.
+func creatingSeed() bool {
+	return *generate || *entropyHex != "" || len(slip39Shares) > 0
+}
+
+// verifyingNodeMessage returns true if the signature of a message by a node
+// key is verified, rather than one by the key of an address.
+func verifyingNodeMessage() bool {
+	return *verifyAddr == "" && (*verifyMessage != "" || *verifySig != "")
+}
+
+// modes are all the modes of the tool, which are mutually exclusive.
+var modes = []mode{
+	{flag: "--generate", set: func() bool { return *generate }},
+	{flag: "--entropy", set: func() bool { return *entropyHex != "" }},
+	{
+		flag: "--slip39-shares",
+		set:  func() bool { return len(slip39Shares) > 0 },
+	},
+	{flag: "--watch-xpub", set: func() bool { return *watchXpub != "" }},
+	{flag: "--batch-file", set: func() bool { return *batchFile != "" }},
+	{flag: "--dry-run", set: func() bool { return *dryRun }},
+	{flag: "--self-test", set: func() bool { return *selfTest }},
+	{flag: "--verify", set: func() bool { return *verifyAddr != "" }},
+	{flag: "--verify-message", set: verifyingNodeMessage},
+	{flag: "--verify-words", set: func() bool { return *verifyWords }},
+
+	// Reformatting the mnemonic is only a mode of its own if there's no
+	// new mnemonic to print instead.
+	{
+		flag: "--print-mnemonic",
+		set: func() bool {
+			return *printMnemonic && !creatingSeed() &&
+				!isFlagSet("new-pass")
+		},
+	},
+
+	// The remaining modes only need the deciphered seed itself.
+	{flag: "--new-pass", set: flagSet("new-pass")},
+	{flag: "--check", set: func() bool { return *checkOnly }},
+
+	// The signer of a message verified with --verify-message can be
+	// checked against --expect-node-pubkey as well.
+	{
+		flag: "--expect-node-pubkey",
+		set: func() bool {
+			return *expectNodePub != "" && !verifyingNodeMessage()
+		},
+	},
+	{
+		flag: "--sign-message",
+		set:  func() bool { return *signMessage != "" },
+	},
+	{flag: "--bip85-index", set: flagSet("bip85-index")},
+	{flag: "--path", set: func() bool { return *derivePath != "" }},
+	{
+		flag: "--compare",
+		set:  func() bool { return *compareMnemonic != "" },
+	},
+	{flag: "--vanity", set: func() bool { return *vanity != "" }},
+	{flag: "--shachain-root", set: func() bool { return *shachainRoot }},
+	{
+		flag: "--remote-pubkey",
+		set:  func() bool { return *remotePubkey != "" },
+	},
+	{flag: "--multisig", set: func() bool { return *multisig != "" }},
+	{
+		flag: "--slip39-split",
+		set:  func() bool { return *slip39Split != "" },
+	},
+	{flag: "--challenge", set: func() bool { return *challenge != "" }},
+	{flag: "--fingerprint", set: func() bool { return *fingerprintOnly }},
+	{flag: "--ecdh", set: func() bool { return *ecdhPeer != "" }},
+	{
+		flag: "--contains-address",
+		set:  func() bool { return *containsAddr != "" },
+	},
+}
+
+var (
+	// createModes are the modes that create a new seed, which is then
+	// recovered in full.
+	createModes = []string{"--generate", "--entropy", "--slip39-shares"}
+
+	// seedModes are the modes that decipher the mnemonic read from the
+	// command line, just like a full recovery does, but then only need
+	// the seed itself.
+	seedModes = []string{
+		"--new-pass", "--check", "--expect-node-pubkey",
+		"--sign-message", "--bip85-index", "--path", "--compare",
+		"--vanity", "--shachain-root", "--remote-pubkey", "--multisig",
+		"--slip39-split", "--challenge", "--fingerprint", "--ecdh",
+		"--contains-address",
+	}
+)
+
+// modeList concatenates the passed lists of modes.
+func modeList(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+
+	return all
+}
+
+// modeOption is a flag that only applies to some of the modes.
+type modeOption struct {
+	// flag is the name of the option, as shown in errors.
+	flag string
+
+	// set returns true if the option was given on the command line.
+	set func() bool
+
+	// modes are the flags of the modes the option can be used with, where
+	// fullRecovery stands for the full recovery of an existing seed.
+	modes []string
+
+	// usage describes the modes the option can be used with, if listing
+	// them would be too long.
+	usage string
+}
+
+// modeOptions are the options that only apply to some of the modes.
+var modeOptions = []modeOption{
+	{
+		flag: "--out",
+		set:  func() bool { return *outPath != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--watch-xpub"}, createModes,
+		),
+		usage: "to write the results of a full recovery",
+	},
+	{
+		flag: "--qr-dir",
+		set:  func() bool { return *qrDir != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--watch-xpub"}, createModes,
+		),
+		usage: "to write the results of a full recovery",
+	},
+	{
+		flag: "--pass",
+		set:  func() bool { return *aezeedPass != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--batch-file"}, createModes,
+			seedModes,
+		),
+		usage: "with a seed to decipher or encipher",
+	},
+	{
+		flag: "--pass-env",
+		set:  func() bool { return *passEnv != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--batch-file"}, createModes,
+			seedModes,
+		),
+		usage: "with a seed to decipher or encipher",
+	},
+	{
+		flag: "--recover-word",
+		set:  func() bool { return *recoverWord > 0 },
+		modes: modeList(
+			[]string{fullRecovery}, seedModes,
+		),
+		usage: "to decipher a single mnemonic",
+	},
+	{
+		flag: "--pass-list",
+		set:  func() bool { return *passList != "" },
+		modes: modeList(
+			[]string{fullRecovery}, seedModes,
+		),
+		usage: "to decipher a single mnemonic",
+	},
+	{
+		flag: "--min-birthday",
+		set:  func() bool { return *minBirthdayStr != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--batch-file"}, seedModes,
+		),
+		usage: "to decipher existing seeds",
+	},
+	{
+		flag: "--scb-file",
+		set:  func() bool { return *scbFile != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--batch-file"}, createModes,
+		),
+		usage: "with a full recovery",
+	},
+	{
+		flag: "--scb-dir",
+		set:  func() bool { return *scbDir != "" },
+		modes: modeList(
+			[]string{fullRecovery, "--batch-file"}, createModes,
+		),
+		usage: "with a full recovery",
+	},
+	{
+		flag:  "--birthday",
+		set:   func() bool { return *birthdayStr != "" },
+		modes: createModes,
+	},
+	{
+		flag:  "--slip39-pass",
+		set:   func() bool { return *slip39Pass != "" },
+		modes: []string{"--slip39-shares", "--slip39-split"},
+	},
+	{
+		flag:  "--sign-addr-index",
+		set:   flagSet("sign-addr-index"),
+		modes: []string{"--sign-message"},
+	},
+	{
+		flag:  "--sign-addr-type",
+		set:   flagSet("sign-addr-type"),
+		modes: []string{"--sign-message"},
+	},
+	{
+		flag:  "--bip85-app",
+		set:   flagSet("bip85-app"),
+		modes: []string{"--bip85-index"},
+	},
+	{
+		flag:  "--bip85-words",
+		set:   flagSet("bip85-words"),
+		modes: []string{"--bip85-index"},
+	},
+	{
+		flag:  "--bip85-bytes",
+		set:   flagSet("bip85-bytes"),
+		modes: []string{"--bip85-index"},
+	},
+	{
+		flag:  "--addr-type",
+		set:   flagSet("addr-type"),
+		modes: []string{"--path"},
+	},
+	{
+		flag:  "--pass2",
+		set:   flagSet("pass2"),
+		modes: []string{"--compare"},
+	},
+	{
+		flag:  "--vanity-addr-type",
+		set:   flagSet("vanity-addr-type"),
+		modes: []string{"--vanity"},
+	},
+	{
+		flag:  "--vanity-max",
+		set:   flagSet("vanity-max"),
+		modes: []string{"--vanity"},
+	},
+	{
+		flag:  "--shachain-index",
+		set:   flagSet("shachain-index"),
+		modes: []string{"--shachain-root"},
+	},
+	{
+		flag:  "--commit-height",
+		set:   flagSet("commit-height"),
+		modes: []string{"--shachain-root"},
+	},
+	{
+		flag:  "--funding-count",
+		set:   flagSet("funding-count"),
+		modes: []string{"--remote-pubkey"},
+	},
+	{
+		flag:  "--cosigner",
+		set:   func() bool { return len(cosigners) > 0 },
+		modes: []string{"--multisig"},
+	},
+	{
+		flag:  "--prove-addr-index",
+		set:   flagSet("prove-addr-index"),
+		modes: []string{"--challenge"},
+	},
+	{
+		flag:  "--prove-addr-type",
+		set:   flagSet("prove-addr-type"),
+		modes: []string{"--challenge"},
+	},
+	{
+		flag:  "--watch-addr-type",
+		set:   func() bool { return *watchAddrType != "" },
+		modes: []string{"--watch-xpub"},
+	},
+}
+
+// joinFlags joins the passed flags into a list of the form "A, B or C".
+func joinFlags(flags []string) string {
+	if len(flags) < 2 {
+		return strings.Join(flags, "")
+	}
+
+	return strings.Join(flags[:len(flags)-1], ", ") + " or " +
+		flags[len(flags)-1]
+}
+
+// selectedMode returns the flag of the mode selected on the command line, or
+// fullRecovery if none was. An error is returned if more than one mode was
+// selected.
+func selectedMode() (string, error) {
+	selected := fullRecovery
+	for _, m := range modes {
+		if !m.set() {
+			continue
+		}
+		if selected != fullRecovery {
+			return "", fmt.Errorf("%v can't be used with %v",
+				m.flag, selected)
+		}
+		selected = m.flag
+	}
+
+	return selected, nil
+}
+
+// checkModes checks that at most one mode was selected on the command line,
+// and that each option given applies to it.
+func checkModes() error {
+	selected, err := selectedMode()
+	if err != nil {
+		return err
+	}
+
+	for _, opt := range modeOptions {
+		if !opt.set() {
+			continue
+		}
+
+		var applies bool
+		for _, m := range opt.modes {
+			if m == selected {
+				applies = true
+			}
+		}
+		if applies {
+			continue
+		}
+
+		usage := opt.usage
+		if usage == "" {
+			usage = "with " + joinFlags(opt.modes)
+		}
+		if selected == fullRecovery {
+			return fmt.Errorf("%v can only be used %v", opt.flag,
+				usage)
+		}
+
+		return fmt.Errorf("%v can only be used %v, not with %v",
+			opt.flag, usage, selected)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// stringList is a flag that can be given multiple times, collecting each of
+// its values.
+type stringList []string
+
+// String returns the values of the flag, separated by commas.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds another value to the flag.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseMultisig parses a multisig policy of the form M-of-N.
+func parseMultisig(policy string) (int, int, error) {
+	parts := strings.Split(policy, "-of-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected a policy of the form "+
+			"M-of-N, instead got %q", policy)
+	}
+
+	required, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid number of required "+
+			"signatures: %w", err)
+	}
+	numKeys, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid number of keys: %w", err)
+	}
+	if required < 1 || required > numKeys ||
+		numKeys > aezeedcheck.MaxMultisigKeys {
+
+		return 0, 0, fmt.Errorf("expected 1 <= M <= N <= %v, "+
+			"instead got %v-of-%v", aezeedcheck.MaxMultisigKeys,
+			required, numKeys)
+	}
+
+	return required, numKeys, nil
+}
+
+// printMultisig derives our share of the multisig wallet given with
+// --multisig and --cosigner from the cipher seed, and prints it along with the
+// descriptors and addresses of the wallet.
+func printMultisig(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed, required int) error {
+
+	wallet, err := aezeedcheck.DeriveMultisig(
+		cfg, cipherSeed, required, cosigners,
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"multisig wallet: %w", err))
+	}
+
+	policy := fmt.Sprintf("%v-of-%v", required, len(cosigners)+1)
+	fmt.Fprintf(w, "Multisig account xpub: %v [%v]\n", wallet.Xpub,
+		wallet.AccountPath)
+	fmt.Fprintf(w, "%v external descriptor: %v\n", policy,
+		wallet.Descriptors[0])
+	fmt.Fprintf(w, "%v internal (change) descriptor: %v\n", policy,
+		wallet.Descriptors[1])
+
+	for _, a := range wallet.Addrs {
+		fmt.Fprintf(w, "%v p2wsh address #%v: %v [%v/%v/%v]\n", policy,
+			a.Index, a.Addr.EncodeAddress(), wallet.AccountPath,
+			a.Branch, a.Index)
+	}
+
+	return nil
+}
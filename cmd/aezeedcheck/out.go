@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/lightninglabs/aezeedcheck"
+)
+
+// writeResults runs the recovery of the passed config, and writes its results
+// to w, or to the file given by --out. Errors of the recovery itself are
+// assigned the passed exit code.
+func writeResults(w io.Writer, cfg aezeedcheck.Config, runErrCode int) error {
+	if *outPath == "" {
+		return withExitCode(runErrCode, aezeedcheck.Run(cfg, w))
+	}
+
+	// We'll write the results to a temporary file in the same directory
+	// first, and only rename it once it's complete, so a crash never
+	// leaves a partially written file behind. The temporary file is only
+	// readable by the user, as the results may contain private keys.
+	dir, name := filepath.Split(*outPath)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return fmt.Errorf("unable to create output file: %w", err)
+	}
+	tempPath := f.Name()
+
+	if err := writeResultsFile(cfg, f, runErrCode); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, *outPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("unable to write output file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote results to %v\n", *outPath)
+
+	return nil
+}
+
+// writeResultsFile runs the recovery of the passed config, writing its results
+// to f, which is synced to disk and closed before returning.
+func writeResultsFile(cfg aezeedcheck.Config, f *os.File,
+	runErrCode int) error {
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write output file: %w", err)
+	}
+
+	if err := aezeedcheck.Run(cfg, f); err != nil {
+		f.Close()
+		return withExitCode(runErrCode, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write output file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write output file: %w", err)
+	}
+
+	return nil
+}
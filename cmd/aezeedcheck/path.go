@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// printPathAddr derives the key at the passed custom derivation path from the
+// cipher seed, and prints it along with its address of the type selected on
+// the command line.
+func printPathAddr(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed, childNums []uint32) error {
+
+	pubKey, addr, err := aezeedcheck.DerivePathAddr(
+		cfg, cipherSeed, childNums, *pathAddrType,
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"path: %w", err))
+	}
+
+	fmt.Fprintf(w, "Path: %v\nPublic key: %x\n%v address: %v\n",
+		aezeedcheck.FormatDerivationPath(childNums),
+		pubKey.SerializeCompressed(), *pathAddrType,
+		addr.EncodeAddress())
+
+	return nil
+}
+
+// printDryRun prints the derivation path of each address that a recovery with
+// the passed config would derive, without deriving any keys.
+func printDryRun(w io.Writer, cfg *aezeedcheck.Config) error {
+	paths, err := aezeedcheck.PlanPaths(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		fmt.Fprintf(w, "%v %v\n", p.AddrType, p.Path)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	// Importing pprof registers its handlers with the default mux, which
+	// is only ever served if --pprof is set.
+	_ "net/http/pprof"
+)
+
+// startPprof serves the profiles of net/http/pprof at the passed address in
+// the background, for as long as the tool runs. To never expose them, or any
+// secrets they may hold, to the network, the address must be a loopback one.
+// An address without a host is bound to localhost.
+func startPprof(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid --pprof address: %w", err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if ip := net.ParseIP(host); host != "localhost" &&
+		(ip == nil || !ip.IsLoopback()) {
+
+		return fmt.Errorf("--pprof must be bound to localhost, "+
+			"instead got %q", host)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("unable to listen for pprof: %w", err)
+	}
+
+	notef("serving pprof at http://%v/debug/pprof/", listener.Addr())
+	go func() {
+		_ = http.Serve(listener, nil)
+	}()
+
+	return nil
+}
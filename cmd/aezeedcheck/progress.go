@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/btcsuite/golangcrypto/ssh/terminal"
+)
+
+// progressInterval is the minimum time between two updates of a progress
+// indicator, so it doesn't flood the terminal.
+const progressInterval = 200 * time.Millisecond
+
+// progressIndicator reports the progress of a long-running operation on a
+// single line of stderr, which is rewritten on each update. It's disabled if
+// stderr isn't a terminal or --quiet was given, so logs and redirected output
+// stay clean.
+type progressIndicator struct {
+	// enabled signals that the progress should be reported at all.
+	enabled bool
+
+	// shown signals that a progress line is currently shown.
+	shown bool
+
+	// lastUpdate is the time the progress line was last written.
+	lastUpdate time.Time
+}
+
+// newProgressIndicator returns a progress indicator that's only enabled if
+// stderr is a terminal and --quiet wasn't given.
+func newProgressIndicator() *progressIndicator {
+	return &progressIndicator{
+		enabled: !*quiet && terminal.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// update reports that done out of total steps of the operation described by
+// what are complete, with a total of zero if it isn't known upfront. Once done
+// reaches a non-zero total, the operation is complete, and the progress line
+// is cleared, so it never ends up next to the results.
+func (p *progressIndicator) update(what string, done, total uint64) {
+	if !p.enabled {
+		return
+	}
+
+	if total != 0 && done >= total {
+		p.clear()
+		return
+	}
+
+	now := time.Now()
+	if p.shown && now.Sub(p.lastUpdate) < progressInterval {
+		return
+	}
+	p.lastUpdate = now
+	p.shown = true
+
+	// The line is cleared before each update, as it may have been longer
+	// before.
+	if total == 0 {
+		fmt.Fprintf(os.Stderr, "\r\x1b[K%v: %v", what, done)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r\x1b[K%v: %v/%v (%v%%)", what, done, total,
+		done*100/total)
+}
+
+// clear removes the progress line, if one is shown.
+func (p *progressIndicator) clear() {
+	if p.shown {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+		p.shown = false
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// unknownWord is the placeholder for the forgotten word of a mnemonic that
+// should be recovered with --recover-word.
+const unknownWord = "?"
+
+// recoverMissingWord tries every word of the word list at the passed position
+// of the mnemonic, and reports each one that results in a seed that deciphers
+// with the given passphrase. Only if exactly one such word is found, it is
+// returned.
+func recoverMissingWord(m *aezeed.Mnemonic, pos int,
+	pass []byte) (string, error) {
+
+	candidate := *m
+	progress := newProgressIndicator()
+	total := uint64(len(aezeedcheck.WordList))
+
+	var found []string
+	for i, word := range aezeedcheck.WordList {
+		progress.update("Trying words", uint64(i), total)
+		candidate[pos] = word
+
+		// Deciphering is slow by design, so we'll only attempt it if
+		// the candidate matches the checksum of the mnemonic, which
+		// rules out almost all of the wrong words.
+		if aezeedcheck.VerifyChecksum(candidate[:]) != nil {
+			continue
+		}
+		if _, err := candidate.ToCipherSeed(pass); err != nil {
+			continue
+		}
+
+		found = append(found, word)
+	}
+	progress.update("Trying words", total, total)
+
+	fmt.Fprintf(os.Stderr, "Found %v candidate(s) for word %v: %v\n",
+		len(found), pos+1, strings.Join(found, ", "))
+
+	switch len(found) {
+	case 0:
+		return "", withExitCode(exitDecrypt, fmt.Errorf("no word at "+
+			"position %v results in a valid seed, check the other "+
+			"words and the passphrase", pos+1))
+
+	case 1:
+		return found[0], nil
+
+	default:
+		return "", withExitCode(exitDecrypt, fmt.Errorf("%v words at "+
+			"position %v result in a valid seed", len(found),
+			pos+1))
+	}
+}
+
+// readPassList reads the candidate passphrases from the file at the passed
+// path, one per line. Empty lines are skipped.
+func readPassList(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var candidates [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		candidates = append(candidates, []byte(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// findPassphrase tries each of the candidate passphrases listed in the file at
+// the passed path, returning a copy of the first one that deciphers the seed.
+// If emptyFirst is true, then the empty passphrase is tried before any of the
+// candidates.
+func findPassphrase(m *aezeed.Mnemonic, path string,
+	emptyFirst bool) ([]byte, error) {
+
+	// If the words don't match the checksum, then no passphrase will
+	// work, so we'll bail out before trying any of them.
+	if err := aezeedcheck.VerifyChecksum(m[:]); err != nil {
+		return nil, err
+	}
+
+	candidates, err := readPassList(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %v: %w", path, err)
+	}
+	defer func() {
+		for _, pass := range candidates {
+			aezeedcheck.ZeroBytes(pass)
+		}
+	}()
+
+	if emptyFirst {
+		candidates = append([][]byte{nil}, candidates...)
+	}
+
+	progress := newProgressIndicator()
+	total := uint64(len(candidates))
+	defer progress.clear()
+
+	for i, pass := range candidates {
+		progress.update("Trying passphrases", uint64(i), total)
+
+		_, err := m.ToCipherSeed(pass)
+		switch err {
+		case nil:
+			progress.clear()
+			fmt.Fprintf(os.Stderr, "Found passphrase at "+
+				"candidate %v\n", i+1)
+			return append([]byte(nil), pass...), nil
+
+		// Any error other than a wrong passphrase means that no
+		// passphrase will work.
+		case aezeed.ErrInvalidPass:
+
+		default:
+			return nil, err
+		}
+	}
+
+	return nil, withExitCode(exitDecrypt, fmt.Errorf("none of the %v "+
+		"candidate passphrases deciphered the seed", len(candidates)))
+}
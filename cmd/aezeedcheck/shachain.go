@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// printShachain derives the shachain root of the channel selected with
+// --shachain-index from the cipher seed, and prints it. If a commitment height
+// was given, then the per-commitment secret and point at that height are
+// printed as well.
+func printShachain(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	root, err := aezeedcheck.DeriveRevocationRoot(
+		cfg, cipherSeed, uint32(*shachainIndex),
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"revocation root: %w", err))
+	}
+	defer aezeedcheck.ZeroBytes(root[:])
+
+	path := aezeedcheck.KeyPath{
+		Purpose:  keychain.BIP0043Purpose,
+		CoinType: params.CoinType,
+		Account:  uint32(keychain.KeyFamilyRevocationRoot),
+		Branch:   aezeedcheck.ExternalBranch,
+		Index:    uint32(*shachainIndex),
+	}
+	fmt.Fprintf(w, "Shachain root: %x [%v]\n", root[:], path)
+
+	if !isFlagSet("commit-height") {
+		return nil
+	}
+
+	secret, err := aezeedcheck.PerCommitmentSecret(root, *commitHeight)
+	if err != nil {
+		return err
+	}
+	defer aezeedcheck.ZeroBytes(secret[:])
+
+	point := aezeedcheck.PerCommitmentPoint(secret)
+	fmt.Fprintf(w, "Per-commitment secret at height %v: %x\n"+
+		"Per-commitment point at height %v: %x\n", *commitHeight,
+		secret[:], *commitHeight, point.SerializeCompressed())
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// parseNodePub parses the passed hex-encoded node pubkey, and returns its
+// compressed serialization.
+func parseNodePub(pubHex string) ([]byte, error) {
+	pub, err := hex.DecodeString(pubHex)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := btcec.ParsePubKey(pub, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.SerializeCompressed(), nil
+}
+
+// signNodeMessage signs the message given on the command line with the node
+// key derived from the passed cipher seed, and prints the signature.
+func signNodeMessage(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	nodeKey, err := aezeedcheck.DeriveNodePrivKey(cipherSeed, params)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"node key: %w", err))
+	}
+	defer aezeedcheck.ZeroPrivKey(nodeKey)
+
+	sig, err := aezeedcheck.SignNodeMessage(nodeKey, []byte(*signMessage))
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to sign "+
+			"message: %w", err))
+	}
+
+	fmt.Fprintf(w, "Node pub key: %x\nSignature: %v\n",
+		nodeKey.PubKey().SerializeCompressed(), sig)
+
+	return nil
+}
+
+// printECDH prints the ECDH shared secret of the node key derived from the
+// passed cipher seed and the given compressed peer pubkey.
+func printECDH(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed, peerPub []byte) error {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	peerKey, err := btcec.ParsePubKey(peerPub, btcec.S256())
+	if err != nil {
+		return fmt.Errorf("invalid --ecdh: %w", err)
+	}
+
+	nodeKey, err := aezeedcheck.DeriveNodePrivKey(cipherSeed, params)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to derive "+
+			"node key: %w", err))
+	}
+	defer aezeedcheck.ZeroPrivKey(nodeKey)
+
+	secret := aezeedcheck.NodeECDH(nodeKey, peerKey)
+	defer aezeedcheck.ZeroBytes(secret[:])
+
+	fmt.Fprintf(w, "Node pub key: %x\nPeer pub key: %x\nShared "+
+		"secret: %x\n", nodeKey.PubKey().SerializeCompressed(),
+		peerPub, secret[:])
+
+	return nil
+}
+
+// signAddrMessage signs the message given on the command line with the key of
+// the selected address derived from the passed cipher seed, and prints the
+// signature along with the address.
+func signAddrMessage(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	addr, sig, err := aezeedcheck.SignAddrMessage(
+		cfg, cipherSeed, *signAddrType, uint32(*signAddrIndex),
+		[]byte(*signMessage),
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to sign "+
+			"message: %w", err))
+	}
+
+	fmt.Fprintf(w, "Address: %v\nSignature: %v\n", addr, sig)
+
+	return nil
+}
+
+// proveAddrOwnership signs the challenge given on the command line with the
+// key of the selected address derived from the passed cipher seed, and prints
+// the resulting proof of ownership as JSON.
+func proveAddrOwnership(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	proof, err := aezeedcheck.ProveAddrOwnership(
+		cfg, cipherSeed, *proveAddrType, uint32(*proveAddrIndex),
+		*challenge,
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to prove "+
+			"ownership: %w", err))
+	}
+
+	return aezeedcheck.PrintOwnershipProof(w, proof)
+}
+
+// verifyAddrMessage checks that the message given on the command line was
+// signed in the Bitcoin Signed Message format by the key of the --verify
+// address, and prints that key.
+func verifyAddrMessage(w io.Writer) error {
+	if *verifyMessage == "" || *verifySig == "" {
+		return errors.New("--verify requires --verify-message and " +
+			"--verify-sig")
+	}
+
+	cfg := aezeedcheck.Config{
+		Coin:      *coin,
+		Network:   *network,
+		SignetHRP: *signetHRP,
+	}
+	params, err := cfg.NetParams()
+	if err != nil {
+		return err
+	}
+
+	addr, err := btcutil.DecodeAddress(*verifyAddr, params.Params)
+	if err != nil {
+		return fmt.Errorf("invalid --verify address: %w", err)
+	}
+	if !addr.IsForNet(params.Params) {
+		return fmt.Errorf("--verify address %v isn't for %v %v", addr,
+			*coin, *network)
+	}
+
+	pubKey, err := aezeedcheck.VerifyBitcoinMessage(
+		addr, []byte(*verifyMessage), *verifySig, params.Params,
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("invalid "+
+			"signature: %w", err))
+	}
+
+	fmt.Fprintf(w, "valid signature from address %v with key %x\n", addr,
+		pubKey.SerializeCompressed())
+
+	return nil
+}
+
+// verifyNodeMessage recovers the node key that signed the message given on
+// the command line, and prints it. If an expected node pubkey was given as
+// well, then the recovered key must match it.
+func verifyNodeMessage(w io.Writer) error {
+	if *verifyMessage == "" || *verifySig == "" {
+		return errors.New("--verify-message and --verify-sig must be " +
+			"used together")
+	}
+
+	pubKey, err := aezeedcheck.RecoverNodeMessageKey(
+		[]byte(*verifyMessage), *verifySig,
+	)
+	if err != nil {
+		return err
+	}
+
+	if *expectNodePub != "" {
+		expected, err := parseNodePub(*expectNodePub)
+		if err != nil {
+			return fmt.Errorf("invalid --expect-node-pubkey: %w",
+				err)
+		}
+
+		if !aezeedcheck.NodeKeyMatches(pubKey, expected) {
+			return withExitCode(exitDerive, fmt.Errorf("signature "+
+				"was made by node %x, expected %x",
+				pubKey.SerializeCompressed(), expected))
+		}
+	}
+
+	fmt.Fprintf(w, "valid signature from node %x\n",
+		pubKey.SerializeCompressed())
+
+	return nil
+}
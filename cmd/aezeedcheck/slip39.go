@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// readSLIP39Shares returns the SLIP39 shares given with --slip39-shares. If the
+// only share given is -, then the shares are read from stdin instead, one per
+// line.
+func readSLIP39Shares() ([]string, error) {
+	if len(slip39Shares) != 1 || slip39Shares[0] != mnemonicStdin {
+		warnf("passing SLIP39 shares on the command line leaks them " +
+			"into the process table, use --slip39-shares=- to " +
+			"read them from stdin instead")
+
+		return slip39Shares, nil
+	}
+
+	var shares []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		shares = append(shares, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read SLIP39 shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// newSLIP39Seed creates a new cipher seed with the passed birthday from the
+// master secret reconstructed from the SLIP39 shares, which must be exactly as
+// large as the entropy of an aezeed.
+func newSLIP39Seed(birthday time.Time) (*aezeed.CipherSeed, error) {
+	shares, err := readSLIP39Shares()
+	if err != nil {
+		return nil, err
+	}
+
+	pass := []byte(*slip39Pass)
+	defer aezeedcheck.ZeroBytes(pass)
+
+	secret, err := aezeedcheck.CombineSLIP39Shares(shares, pass)
+	if err != nil {
+		return nil, withExitCode(exitDecrypt, fmt.Errorf("unable to "+
+			"combine SLIP39 shares: %w", err))
+	}
+	defer aezeedcheck.ZeroBytes(secret)
+
+	if len(secret) != aezeed.EntropySize {
+		return nil, fmt.Errorf("the SLIP39 master secret is %v bytes, "+
+			"but an aezeed can only hold %v bytes of entropy",
+			len(secret), aezeed.EntropySize)
+	}
+
+	return newSeedFromEntropy(secret, birthday)
+}
+
+// parseSLIP39Split parses a SLIP39 share policy of the form T-of-N.
+func parseSLIP39Split(policy string) (int, int, error) {
+	parts := strings.Split(policy, "-of-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected a policy of the form "+
+			"T-of-N, instead got %q", policy)
+	}
+
+	threshold, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid threshold: %w", err)
+	}
+	numShares, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid number of shares: %w", err)
+	}
+	if threshold < 1 || threshold > numShares ||
+		numShares > aezeedcheck.SLIP39MaxShares {
+
+		return 0, 0, fmt.Errorf("expected 1 <= T <= N <= %v, "+
+			"instead got %v-of-%v", aezeedcheck.SLIP39MaxShares,
+			threshold, numShares)
+	}
+	if threshold == 1 && numShares > 1 {
+		return 0, 0, errors.New("a threshold of 1 requires a " +
+			"single share, use 1-of-1 instead")
+	}
+
+	return threshold, numShares, nil
+}
+
+// printSLIP39Shares splits the entropy of the cipher seed into the SLIP39
+// shares selected with --slip39-split, encrypted with --slip39-pass, and
+// prints each of them on its own line.
+func printSLIP39Shares(w io.Writer, cipherSeed *aezeed.CipherSeed,
+	threshold, numShares int) error {
+
+	pass := []byte(*slip39Pass)
+	defer aezeedcheck.ZeroBytes(pass)
+
+	shares, err := aezeedcheck.SplitSLIP39Secret(
+		cipherSeed.Entropy[:], threshold, numShares, pass,
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to split "+
+			"entropy: %w", err))
+	}
+
+	for i, share := range shares {
+		fmt.Fprintf(w, "SLIP39 share %v of %v (any %v recover the "+
+			"seed): %v\n", i+1, numShares, threshold, share)
+	}
+
+	return nil
+}
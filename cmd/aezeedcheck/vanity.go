@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lightninglabs/aezeedcheck"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// printVanityAddr searches the cipher seed for the first address that
+// contains the substring passed to --vanity, and prints it along with its
+// index and path.
+func printVanityAddr(w io.Writer, cfg *aezeedcheck.Config,
+	cipherSeed *aezeed.CipherSeed) error {
+
+	path, addr, err := aezeedcheck.FindVanityAddr(
+		cfg, cipherSeed, *vanityAddrType, *vanity,
+		uint32(*vanityMax),
+	)
+	if err != nil {
+		return withExitCode(exitDerive, fmt.Errorf("unable to find "+
+			"vanity address: %w", err))
+	}
+
+	fmt.Fprintf(w, "%v address #%v: %v [%v]\n", *vanityAddrType,
+		path.Index, addr.EncodeAddress(), path)
+
+	return nil
+}
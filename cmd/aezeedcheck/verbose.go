@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strconv"
+)
+
+// verbosityFlag is a boolean flag that can be given multiple times, raising
+// the verbosity by one level each time.
+type verbosityFlag uint8
+
+// String returns the current verbosity level.
+func (v *verbosityFlag) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// Set raises the verbosity by one level, or sets it to the given level if the
+// flag was passed a number, e.g. --verbose=2.
+func (v *verbosityFlag) Set(value string) error {
+	if value == "true" {
+		*v++
+		return nil
+	}
+
+	level, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return err
+	}
+	*v = verbosityFlag(level)
+
+	return nil
+}
+
+// IsBoolFlag signals to the flag package that the flag doesn't require a
+// value.
+func (v *verbosityFlag) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,133 @@
+package aezeedcheck
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// SeedField is one of the fields of two seeds compared by CompareSeeds.
+type SeedField struct {
+	// Name describes the field, e.g. "node pub key".
+	Name string
+
+	// A and B are the values of the field for each of the seeds. They're
+	// left empty for the entropy, which must not be printed.
+	A, B string
+
+	// Match is true if the field is the same for both seeds.
+	Match bool
+}
+
+// CompareSeeds compares the entropy, master fingerprint, node key and first
+// external address of each of the configured address types of two cipher
+// seeds, on the configured network. Only the entropy determines the derived
+// keys, so the birthdays and internal versions of the seeds aren't compared.
+func CompareSeeds(cfg *Config, a, b *aezeed.CipherSeed) ([]SeedField,
+	error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	valuesA, err := seedFieldValues(cfg, params, a)
+	if err != nil {
+		return nil, err
+	}
+	valuesB, err := seedFieldValues(cfg, params, b)
+	if err != nil {
+		return nil, err
+	}
+
+	// The entropy is compared in constant time, and only whether it
+	// matches is reported.
+	fields := []SeedField{{
+		Name: "entropy",
+		Match: subtle.ConstantTimeCompare(
+			a.Entropy[:], b.Entropy[:],
+		) == 1,
+	}}
+	for i, value := range valuesA {
+		fields = append(fields, SeedField{
+			Name:  value.name,
+			A:     value.value,
+			B:     valuesB[i].value,
+			Match: value.value == valuesB[i].value,
+		})
+	}
+
+	return fields, nil
+}
+
+// seedFieldValue is the value of a single public field of a seed.
+type seedFieldValue struct {
+	name  string
+	value string
+}
+
+// seedFieldValues derives the public fields of the passed seed that are
+// compared by CompareSeeds, in the order they're reported.
+func seedFieldValues(cfg *Config, params *NetParams,
+	cipherSeed *aezeed.CipherSeed) ([]seedFieldValue, error) {
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	keyCache := newAccountKeyCache(rootKey, params.CoinType)
+	defer keyCache.zero()
+
+	fingerprint, err := MasterFingerprint(rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute master "+
+			"fingerprint: %w", err)
+	}
+
+	nodePub, err := keyCache.firstKey(
+		keychain.BIP0043Purpose, keychain.KeyFamilyNodeKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive node key: %w", err)
+	}
+
+	values := []seedFieldValue{
+		{
+			name:  "master fingerprint",
+			value: hex.EncodeToString(fingerprint[:]),
+		},
+		{
+			name: "node pub key",
+			value: hex.EncodeToString(
+				nodePub.SerializeCompressed(),
+			),
+		},
+	}
+
+	for _, t := range selectedAddrTypes(cfg) {
+		pub, err := keyCache.firstKey(t.purpose, 0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive %v key: %w",
+				t.name, err)
+		}
+
+		addr, err := t.keyToAddr(pub, params.Params)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive %v addr: %w",
+				t.name, err)
+		}
+
+		values = append(values, seedFieldValue{
+			name:  fmt.Sprintf("%v address #0", t.name),
+			value: addr.EncodeAddress(),
+		})
+	}
+
+	return values, nil
+}
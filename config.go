@@ -0,0 +1,561 @@
+package aezeedcheck
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// FormatText is the default, human readable output format.
+	FormatText = "text"
+
+	// FormatJSON is the machine readable JSON output format.
+	FormatJSON = "json"
+
+	// FormatCSV prints one CSV row per derived address, for bulk import.
+	FormatCSV = "csv"
+
+	// FormatTable is the human readable output format, with the derived
+	// addresses aligned in a table.
+	FormatTable = "table"
+
+	// FormatBitcoind prints a JSON array of descriptors that can be passed
+	// to bitcoind's importdescriptors RPC.
+	FormatBitcoind = "bitcoind"
+
+	// FormatElectrum prints a minimal Electrum wallet file of a watch-only
+	// wallet for the BIP0084 account.
+	FormatElectrum = "electrum"
+
+	// FormatSparrow prints a wallet file in the Specter Desktop format,
+	// which Sparrow imports as a watch-only wallet of a single account.
+	FormatSparrow = "sparrow"
+
+	// FormatColdcard prints the account xpubs and first addresses in the
+	// layout of the wallet summary file of a Coldcard signer.
+	FormatColdcard = "coldcard"
+)
+
+const (
+	// MaxAccounts is the most accounts that can be derived in a single
+	// run.
+	MaxAccounts = 1000
+
+	// MaxAddrs is the most addresses that can be derived on each branch
+	// in a single run, including the gap limit of a bulk import. As the
+	// addresses of a branch are held in memory until they're printed,
+	// this keeps a typo from exhausting it.
+	MaxAddrs = 1000000
+)
+
+// ProgressFunc reports that done out of total steps of the long-running
+// operation described by what are complete. The total is zero as long as it
+// isn't known.
+type ProgressFunc func(what string, done, total uint64)
+
+// WarnFunc reports a problem that doesn't stop the recovery, but that the user
+// should be made aware of.
+type WarnFunc func(msg string)
+
+// Config holds the seed to recover, along with all the options that determine
+// what's derived from it and how the results are printed by Run.
+type Config struct {
+	// Mnemonic is the aezeed mnemonic of the seed.
+	Mnemonic aezeed.Mnemonic
+
+	// Passphrase is the optional passphrase the seed is enciphered with.
+	// It's zeroed by Run as soon as the seed is deciphered.
+	Passphrase []byte
+
+	// WatchXpub is an account extended public key that addresses should
+	// be derived from in watch-only mode, instead of from the seed. Only
+	// addresses can be derived in this mode.
+	WatchXpub string
+
+	// WatchAddrType optionally overrides the type of the addresses derived
+	// from WatchXpub, which is otherwise detected from its version bytes.
+	WatchAddrType string
+
+	// ShowMnemonic signals that the mnemonic should be included in the
+	// output, e.g. because the seed was just generated.
+	ShowMnemonic bool
+
+	// MnemonicLines signals that the mnemonic, if it's shown, should be
+	// printed with one numbered word per line rather than in columns.
+	MnemonicLines bool
+
+	// Coin is the coin the seed was used for, which must be one of the
+	// keys of CoinParams.
+	Coin string
+
+	// Network is the name of the network the seed was used on, which must
+	// be one of the networks of Coin in CoinParams.
+	Network string
+
+	// CoinType optionally overrides the BIP0044 coin type of every
+	// derivation path, which is otherwise determined by Coin and Network.
+	// It only changes the paths, not the encoding of the addresses.
+	CoinType *uint32
+
+	// SignetHRP is an optional bech32 HRP that overrides the default one
+	// of signet, as custom signets may use their own address prefix.
+	SignetHRP string
+
+	// BirthdayFormat is the format the birthday of the seed is printed in
+	// by the text and CSV formats, which is one of the BirthdayFormat
+	// constants. Go's default format is used if it's empty.
+	BirthdayFormat string
+
+	// Format is the format the results are printed in.
+	Format string
+
+	// Verbosity is the level of detail that the steps of the derivation
+	// are logged with to the standard logger, which is one of the
+	// Verbosity constants. Nothing is logged if it's zero, and secret key
+	// material is never logged at any level.
+	Verbosity uint8
+
+	// Progress, if set, is called periodically while scanning for used
+	// addresses, with the number of addresses checked so far. Once the
+	// scan of a branch is complete, it's called with done equal to total.
+	Progress ProgressFunc
+
+	// Warn, if set, is called with each warning about the seed that
+	// doesn't stop the recovery, such as an implausible birthday. If it
+	// isn't set, warnings are logged to the standard logger.
+	Warn WarnFunc
+
+	// StrictBirthday signals that a seed that deciphers to a birthday in
+	// the future, or before the aezeed epoch, should fail the recovery
+	// instead of only causing a warning.
+	StrictBirthday bool
+
+	// MinBirthday, if set, makes a seed that deciphers to a birthday
+	// before it fail the recovery, to only process seeds created after a
+	// cutoff.
+	MinBirthday time.Time
+
+	// CSVComments signals that the birthday, master fingerprint and node
+	// key should be included as comment lines when printing CSV.
+	CSVComments bool
+
+	// AddrTypes optionally limits the derived address types to those
+	// named, e.g. p2wkh or p2tr. All types are derived if it's empty. The
+	// types are always derived in the same order, no matter the order
+	// they're named in.
+	AddrTypes []string
+
+	// Count is the number of addresses to derive for each address type.
+	Count uint32
+
+	// FirstIndex is the index of the first address derived on each
+	// branch, so that addresses can be derived from where an earlier
+	// recovery stopped, rather than always from index 0.
+	FirstIndex uint32
+
+	// Accounts are the indexes of the accounts that addresses are derived
+	// from, in the order they're printed. Only account 0 is derived if
+	// none are given.
+	Accounts []uint32
+
+	// Branch selects which branches of each account addresses are derived
+	// from: external, internal or both.
+	Branch string
+
+	// BulkImport signals that GapLimit additional addresses should be
+	// derived on each branch, and printed in a format suitable for bulk
+	// import.
+	BulkImport bool
+
+	// GapLimit is the number of addresses past Count that a wallet should
+	// look ahead. It's used both for bulk imports and for the range of the
+	// descriptors imported into bitcoind.
+	GapLimit uint32
+
+	// LegacyUncompressed signals that the legacy p2pkh address should be
+	// computed from the uncompressed serialization of the key.
+	LegacyUncompressed bool
+
+	// Uncompressed signals that the uncompressed serialization of the node
+	// key and any requested key family or key locator keys should be
+	// printed alongside the compressed one. It implies LegacyUncompressed.
+	Uncompressed bool
+
+	// Scripts signals that the output script of each address should be
+	// printed alongside it.
+	Scripts bool
+
+	// KeyFamilies signals that the first key of each of lnd's key
+	// families should be derived and printed.
+	KeyFamilies bool
+
+	// KeyLocator, if set, identifies a single key of one of lnd's key
+	// families that should be derived and printed.
+	KeyLocator *keychain.KeyLocator
+
+	// KeyLocatorPriv signals that the private key identified by
+	// KeyLocator should be printed as well. It's up to the caller to make
+	// sure this is intended.
+	KeyLocatorPriv bool
+
+	// TowerSessions is the number of session keys of lnd's watchtower
+	// client that should be derived and printed, along with the identity
+	// key of lnd's watchtower. The keys follow the key family layout of
+	// lnd v0.7, where the client derives a key from the tower session
+	// family for each session it negotiates, and the tower derives its
+	// identity key from the tower id family.
+	TowerSessions uint32
+
+	// SCB is an optional packed static channel backup, e.g. the contents
+	// of lnd's channel.backup file, that should be decrypted with the
+	// seed and have its channels printed.
+	SCB []byte
+
+	// SCBDir is the optional path of a directory, e.g. a copy of lnd's
+	// data directory, that's searched for static channel backup files
+	// ending in .backup. Each of them is decrypted with the seed, and the
+	// channels they contain are merged with those of SCB. Files that can't
+	// be decrypted are skipped.
+	SCBDir string
+
+	// Xpub signals that the extended public key of each account should be
+	// printed.
+	Xpub bool
+
+	// Slip132 signals that the account extended public keys should be
+	// encoded with the SLIP-0132 version bytes of their key scope.
+	Slip132 bool
+
+	// Descriptors signals that the output descriptors of each account,
+	// as well as of each p2tr address, should be printed.
+	Descriptors bool
+
+	// WIF signals that the WIF encoded private key of each derived
+	// address should be printed. It's up to the caller to make sure this
+	// is intended.
+	WIF bool
+
+	// ShowEntropy signals that the raw entropy of the seed should be
+	// printed. It's up to the caller to make sure this is intended.
+	ShowEntropy bool
+
+	// BIP39 signals that the entropy of the seed should be printed as a
+	// BIP0039 mnemonic. It's up to the caller to make sure this is
+	// intended.
+	BIP39 bool
+
+	// Xprv signals that the extended private key of each account should
+	// be printed. It's up to the caller to make sure this is intended.
+	Xprv bool
+
+	// QR signals that each derived address, and any account xpub or
+	// descriptor, should also be printed as a QR code. This is only
+	// supported by the text format.
+	QR bool
+
+	// QRDir is the optional path of a directory that a QR code PNG file
+	// of each derived address, and of each account xpub, is written into.
+	// The files are named after the derivation path of their key.
+	QRDir string
+
+	// Esplora is the optional base URL of an Esplora server, e.g.
+	// https://blockstream.info/api, to look up the confirmed balance of
+	// each derived address from. No network requests are made unless it's
+	// set.
+	Esplora string
+
+	// EsploraTimeout is the timeout of each request to the Esplora
+	// server.
+	EsploraTimeout time.Duration
+
+	// Proxy is the optional URL of a SOCKS5 proxy, e.g.
+	// socks5://127.0.0.1:9050 for Tor, that all requests to the Esplora
+	// server are routed through.
+	Proxy string
+
+	// Scan signals that, instead of deriving Count addresses, the used
+	// addresses on each branch should be found by looking up addresses
+	// from the Esplora server until GapLimit consecutive unused ones are
+	// found. Only the used addresses are printed.
+	Scan bool
+}
+
+// DefaultConfig returns a config with the default options, which derives and
+// prints the first external address of each type on mainnet. Only the seed
+// itself still needs to be filled in.
+func DefaultConfig() Config {
+	return Config{
+		Coin:           CoinBitcoin,
+		Network:        "mainnet",
+		Format:         FormatText,
+		Count:          1,
+		Branch:         "external",
+		GapLimit:       20,
+		EsploraTimeout: DefaultEsploraTimeout,
+	}
+}
+
+// Validate checks that the options of the config are consistent, without
+// looking at the seed itself.
+func (c *Config) Validate() error {
+	_, err := c.NetParams()
+	if err != nil {
+		return err
+	}
+
+	if c.WatchXpub != "" {
+		if err := c.validateWatchOnly(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateAddrTypes(c); err != nil {
+		return err
+	}
+
+	if c.Count == 0 {
+		return errors.New("count must be at least 1")
+	}
+	numAddrs := uint64(c.Count)
+	if c.BulkImport {
+		numAddrs += uint64(c.GapLimit)
+	}
+	if numAddrs > MaxAddrs || c.GapLimit > MaxAddrs {
+		return fmt.Errorf("at most %v addresses can be derived on "+
+			"each branch", MaxAddrs)
+	}
+
+	// The address indexes are derived as non-hardened children, so even
+	// the last one of the gap limit must stay below the hardened range.
+	lastIndex := uint64(c.FirstIndex) + uint64(c.Count) - 1
+	if c.BulkImport || c.Format == FormatBitcoind {
+		lastIndex += uint64(c.GapLimit)
+	}
+	if lastIndex >= hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("address indexes must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+	if c.FirstIndex > 0 {
+		switch {
+		case c.Scan:
+			return errors.New("a scan always starts at index 0")
+
+		case c.Format == FormatElectrum || c.Format == FormatSparrow ||
+			c.Format == FormatColdcard:
+
+			return fmt.Errorf("the %v format describes the whole "+
+				"account, so it can't start at index %v",
+				c.Format, c.FirstIndex)
+		}
+	}
+
+	if len(c.Accounts) > MaxAccounts {
+		return fmt.Errorf("at most %v accounts can be derived at once",
+			MaxAccounts)
+	}
+	for _, account := range c.Accounts {
+		if account >= hdkeychain.HardenedKeyStart {
+			return fmt.Errorf("account must be below %v",
+				hdkeychain.HardenedKeyStart)
+		}
+	}
+	if len(c.Accounts) > 1 {
+		switch c.Format {
+		case FormatElectrum, FormatSparrow, FormatColdcard:
+			return fmt.Errorf("the %v format can only hold a "+
+				"single account", c.Format)
+		}
+	}
+
+	if _, ok := branchSelections[c.Branch]; !ok {
+		return fmt.Errorf("unknown branch %q, expected one of: "+
+			"external, internal, both", c.Branch)
+	}
+
+	// Both the family and index of a key locator are derived as children
+	// of their parent keys, with the family being hardened, so neither may
+	// reach into the range of hardened children itself.
+	if c.KeyLocator != nil {
+		if uint32(c.KeyLocator.Family) >= hdkeychain.HardenedKeyStart {
+			return fmt.Errorf("key family must be below %v",
+				hdkeychain.HardenedKeyStart)
+		}
+		if c.KeyLocator.Index >= hdkeychain.HardenedKeyStart {
+			return fmt.Errorf("key index must be below %v",
+				hdkeychain.HardenedKeyStart)
+		}
+	}
+	if c.TowerSessions >= hdkeychain.HardenedKeyStart {
+		return fmt.Errorf("the number of tower sessions must be "+
+			"below %v", hdkeychain.HardenedKeyStart)
+	}
+	if c.KeyLocatorPriv && c.KeyLocator == nil {
+		return errors.New("a key locator is required to print its " +
+			"private key")
+	}
+
+	if c.QR && (c.Format != FormatText || c.BulkImport) {
+		return errors.New("QR codes can only be printed in the text " +
+			"format, without a bulk import")
+	}
+
+	if c.Scan && c.Esplora == "" {
+		return errors.New("an Esplora server is required to scan for " +
+			"used addresses")
+	}
+	if c.Scan && c.GapLimit == 0 {
+		return errors.New("the gap limit must be at least 1 to scan " +
+			"for used addresses")
+	}
+	if c.Scan && c.BulkImport {
+		return errors.New("the addresses found by a scan can't be " +
+			"printed for a bulk import")
+	}
+
+	if c.Esplora != "" {
+		if err := validateEsploraURL(c.Esplora); err != nil {
+			return err
+		}
+		switch c.Format {
+		case FormatBitcoind, FormatElectrum, FormatSparrow,
+			FormatColdcard:
+
+			return errors.New("balances can't be included in the " +
+				"bitcoind, electrum, sparrow or coldcard " +
+				"formats")
+		}
+	}
+	if c.Proxy != "" {
+		if c.Esplora == "" {
+			return errors.New("a proxy can only be used with an " +
+				"Esplora server")
+		}
+		if _, err := parseProxyURL(c.Proxy); err != nil {
+			return err
+		}
+	}
+
+	if err := validateBirthdayFormat(c.BirthdayFormat); err != nil {
+		return err
+	}
+
+	switch c.Format {
+	case FormatText, FormatJSON, FormatCSV, FormatTable, FormatBitcoind,
+		FormatElectrum, FormatSparrow, FormatColdcard:
+
+	default:
+		return fmt.Errorf("unknown format %q, expected one of: text, "+
+			"json, csv, table, bitcoind, electrum, sparrow, "+
+			"coldcard", c.Format)
+	}
+
+	// The Electrum wallet is made up of the BIP0084 account alone.
+	if c.Format == FormatElectrum && len(c.AddrTypes) > 0 {
+		var found bool
+		for _, name := range c.AddrTypes {
+			found = found || name == "p2wkh"
+		}
+		if !found {
+			return errors.New("the electrum format requires the " +
+				"p2wkh address type")
+		}
+	}
+
+	// The Sparrow wallet is made up of a single account, which is the
+	// BIP0084 one unless another address type was selected.
+	if c.Format == FormatSparrow && len(c.AddrTypes) > 1 {
+		return errors.New("the sparrow format requires a single " +
+			"address type")
+	}
+	if c.Format == FormatSparrow && c.Coin != CoinBitcoin {
+		return fmt.Errorf("the sparrow format can only be used with "+
+			"%v", CoinBitcoin)
+	}
+
+	// The Coldcard summary is meant to be shared with a watch-only wallet,
+	// so private keys are strictly kept out of it.
+	if c.Format == FormatColdcard && (c.WIF || c.Xprv || c.ShowEntropy ||
+		c.BIP39 || c.KeyLocatorPriv) {
+
+		return errors.New("private keys can't be included in the " +
+			"coldcard format")
+	}
+	if c.Format == FormatColdcard && len(c.AddrTypes) > 0 {
+		var found bool
+		for _, name := range c.AddrTypes {
+			found = found || name == "p2wkh" || name == "np2wkh" ||
+				name == "p2pkh"
+		}
+		if !found {
+			return errors.New("the coldcard format requires the " +
+				"p2wkh, np2wkh or p2pkh address type")
+		}
+	}
+
+	return nil
+}
+
+// accounts returns the indexes of the accounts that addresses are derived
+// from.
+func (c *Config) accounts() []uint32 {
+	if len(c.Accounts) == 0 {
+		return []uint32{0}
+	}
+
+	return c.Accounts
+}
+
+// NetParams returns the parameters of the configured network, with the bech32
+// HRP overridden if requested.
+func (c *Config) NetParams() (*NetParams, error) {
+	networks, ok := CoinParams[c.Coin]
+	if !ok {
+		return nil, fmt.Errorf("unknown coin %q, expected one of: "+
+			"btc, ltc", c.Coin)
+	}
+
+	chainParams, ok := networks[c.Network]
+	switch {
+	case !ok && c.Coin == CoinBitcoin:
+		return nil, fmt.Errorf("unknown network %q, expected one of: "+
+			"mainnet, testnet3, regtest, signet, simnet", c.Network)
+
+	case !ok:
+		return nil, fmt.Errorf("network %q isn't supported for %v, "+
+			"only mainnet is", c.Network, c.Coin)
+	}
+
+	params := &NetParams{
+		Params:   chainParams,
+		CoinType: chainParams.HDCoinType,
+	}
+	if c.CoinType != nil {
+		if *c.CoinType >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("coin type must be below %v",
+				hdkeychain.HardenedKeyStart)
+		}
+
+		params.CoinType = *c.CoinType
+	}
+
+	if c.SignetHRP == "" {
+		return params, nil
+	}
+
+	if params.Params != &SigNetParams {
+		return nil, errors.New("a custom HRP can only be used on " +
+			"signet")
+	}
+
+	customParams := *params.Params
+	customParams.Bech32HRPSegwit = c.SignetHRP
+
+	params.Params = &customParams
+
+	return params, nil
+}
@@ -0,0 +1,249 @@
+package aezeedcheck
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// ExternalBranch is the branch of an account used for receiving
+	// addresses.
+	ExternalBranch = 0
+
+	// InternalBranch is the branch of an account used for change
+	// addresses.
+	InternalBranch = 1
+)
+
+// DeriveFirstKey derives the first key of the external branch of the account
+// identified by the given purpose, coin type, and key family.
+func DeriveFirstKey(rootKey *hdkeychain.ExtendedKey, purpose,
+	coinType uint32,
+	keyFamily keychain.KeyFamily) (*btcec.PublicKey, error) {
+
+	accountKey, err := DeriveAccountKey(
+		rootKey, purpose, coinType, keyFamily,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer accountKey.Zero()
+
+	return DeriveKeyAtIndex(accountKey, ExternalBranch, 0)
+}
+
+// DeriveKeyAtIndex derives the key at the given index of the passed branch of
+// an account key. The account key should be derived once via DeriveAccountKey
+// and then reused for all indexes.
+func DeriveKeyAtIndex(accountKey *hdkeychain.ExtendedKey, branch,
+	index uint32) (*btcec.PublicKey, error) {
+
+	branchKey, err := accountKey.Child(branch)
+	if err != nil {
+		return nil, err
+	}
+	defer branchKey.Zero()
+
+	child, err := branchKey.Child(index)
+	if err != nil {
+		return nil, err
+	}
+	defer child.Zero()
+
+	return child.ECPubKey()
+}
+
+// DeriveAccountKey derives the hardened account key at the path
+// m/purpose'/coinType'/keyFamily' from the passed root key.
+func DeriveAccountKey(rootKey *hdkeychain.ExtendedKey,
+	purpose, coinType uint32,
+	keyFamily keychain.KeyFamily) (*hdkeychain.ExtendedKey, error) {
+
+	purposeKey, err := rootKey.Child(
+		purpose + hdkeychain.HardenedKeyStart,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive purpose key; %w", err)
+	}
+	defer purposeKey.Zero()
+
+	coinTypeKey, err := purposeKey.Child(
+		coinType + hdkeychain.HardenedKeyStart,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate coin type key: %w", err)
+	}
+	defer coinTypeKey.Zero()
+
+	accountKey, err := coinTypeKey.Child(
+		uint32(keyFamily) + hdkeychain.HardenedKeyStart,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive account key: %w", err)
+	}
+
+	return accountKey, nil
+}
+
+// DeriveNodeKey derives lnd's node identity key from the passed cipher seed,
+// using the coin type of the given network just like lnd does.
+func DeriveNodeKey(cipherSeed *aezeed.CipherSeed,
+	params *NetParams) (*btcec.PublicKey, error) {
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	defer rootKey.Zero()
+
+	return DeriveFirstKey(
+		rootKey, keychain.BIP0043Purpose, params.CoinType,
+		keychain.KeyFamilyNodeKey,
+	)
+}
+
+// SeedIdentity holds the public identifiers of a seed, which tell seeds apart
+// without revealing any of their keys.
+type SeedIdentity struct {
+	// Fingerprint is the BIP0032 fingerprint of the master key.
+	Fingerprint [4]byte
+
+	// NodePub is lnd's node identity key.
+	NodePub *btcec.PublicKey
+}
+
+// IdentifySeed derives the master fingerprint and the node identity key of
+// the passed cipher seed on the given network, and nothing else.
+func IdentifySeed(cipherSeed *aezeed.CipherSeed,
+	params *NetParams) (*SeedIdentity, error) {
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	defer rootKey.Zero()
+
+	fingerprint, err := MasterFingerprint(rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute master fingerprint: "+
+			"%w", err)
+	}
+
+	nodePub, err := DeriveFirstKey(
+		rootKey, keychain.BIP0043Purpose, params.CoinType,
+		keychain.KeyFamilyNodeKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive node key: %w", err)
+	}
+
+	return &SeedIdentity{
+		Fingerprint: fingerprint,
+		NodePub:     nodePub,
+	}, nil
+}
+
+// DeriveNodePrivKey derives the private key of lnd's node identity key from
+// the passed cipher seed, using the coin type of the given network.
+func DeriveNodePrivKey(cipherSeed *aezeed.CipherSeed,
+	params *NetParams) (*btcec.PrivateKey, error) {
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	defer rootKey.Zero()
+
+	nodeKey, err := DeriveKeyLocator(
+		rootKey, params.CoinType, keychain.KeyLocator{
+			Family: keychain.KeyFamilyNodeKey,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer nodeKey.Zero()
+
+	return nodeKey.ECPrivKey()
+}
+
+// NodeKeyMatches returns true if the passed node key matches the expected
+// serialized compressed public key. The comparison is done in constant time.
+func NodeKeyMatches(nodePub *btcec.PublicKey, expected []byte) bool {
+	return subtle.ConstantTimeCompare(
+		nodePub.SerializeCompressed(), expected,
+	) == 1
+}
+
+// DeriveKeyLocator derives the extended key that lnd addresses with the
+// passed key locator, at the path m/1017'/coinType'/family'/0/index.
+func DeriveKeyLocator(rootKey *hdkeychain.ExtendedKey, coinType uint32,
+	loc keychain.KeyLocator) (*hdkeychain.ExtendedKey, error) {
+
+	accountKey, err := DeriveAccountKey(
+		rootKey, keychain.BIP0043Purpose, coinType, loc.Family,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer accountKey.Zero()
+
+	return deriveLocatorChild(accountKey, loc.Index)
+}
+
+// deriveLocatorChild derives the key at the given index of the external
+// branch of a key family's account key, which is where lnd keeps all keys it
+// addresses with a key locator.
+func deriveLocatorChild(accountKey *hdkeychain.ExtendedKey,
+	index uint32) (*hdkeychain.ExtendedKey, error) {
+
+	branchKey, err := accountKey.Child(ExternalBranch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive branch key: %w", err)
+	}
+	defer branchKey.Zero()
+
+	return branchKey.Child(index)
+}
+
+// KeyPath tracks each element of the BIP0032 derivation path of a key, as
+// the key is derived from the root.
+type KeyPath struct {
+	// Purpose is the hardened BIP0043 purpose of the key scope.
+	Purpose uint32
+
+	// CoinType is the hardened coin type of the key scope.
+	CoinType uint32
+
+	// Account is the hardened account, or key family in the case of lnd.
+	Account uint32
+
+	// Branch is the branch of the account.
+	Branch uint32
+
+	// Index is the index of the key within the branch.
+	Index uint32
+}
+
+// AccountPath renders the derivation path of the account the key belongs to,
+// e.g. m/84'/0'/0'.
+func (p KeyPath) AccountPath() string {
+	return fmt.Sprintf("m/%d'/%d'/%d'", p.Purpose, p.CoinType, p.Account)
+}
+
+// String renders the derivation path, e.g. m/84'/0'/0'/0/3.
+func (p KeyPath) String() string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d/%d", p.Purpose, p.CoinType,
+		p.Account, p.Branch, p.Index)
+}
@@ -0,0 +1,46 @@
+package aezeedcheck
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// benchRootKey returns the mainnet root key of the entropy of the self-test
+// vector.
+func benchRootKey(b *testing.B) *hdkeychain.ExtendedKey {
+	b.Helper()
+
+	entropy, err := hex.DecodeString(selfTestVector.entropy)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rootKey, err := hdkeychain.NewMaster(entropy, &chaincfg.MainNetParams)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return rootKey
+}
+
+// BenchmarkDeriveAccountKey measures the derivation of the BIP0084 account key
+// from the root key, which takes three hardened child derivations.
+func BenchmarkDeriveAccountKey(b *testing.B) {
+	rootKey := benchRootKey(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		accountKey, err := DeriveAccountKey(
+			rootKey, waddrmgr.KeyScopeBIP0084.Purpose,
+			keychain.CoinTypeBitcoin, 0,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+		accountKey.Zero()
+	}
+}
@@ -0,0 +1,149 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const (
+	// descriptorInputCharset is the set of characters a descriptor may
+	// consist of, ordered such that the checksum is able to detect common
+	// errors, as defined by Bitcoin Core.
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// descriptorChecksumCharset is the set of characters the checksum of
+	// a descriptor is encoded with.
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// MasterFingerprint returns the BIP0032 fingerprint of the passed master key,
+// which is the first 4 bytes of the hash160 of its compressed public key.
+func MasterFingerprint(rootKey *hdkeychain.ExtendedKey) ([4]byte, error) {
+	var fingerprint [4]byte
+
+	rootPub, err := rootKey.ECPubKey()
+	if err != nil {
+		return fingerprint, err
+	}
+	copy(fingerprint[:], btcutil.Hash160(rootPub.SerializeCompressed()))
+
+	return fingerprint, nil
+}
+
+// BuildDescriptor returns the checksummed output descriptor of the given
+// branch of an account, e.g. wpkh([fingerprint/84'/0'/0']xpub/0/*)#checksum.
+// The descriptor format should contain a single placeholder for the key
+// expression, e.g. wpkh(%s).
+func BuildDescriptor(descriptorFmt string, fingerprint [4]byte,
+	accountPath KeyPath, accountPub string, branch uint32) (string, error) {
+
+	key := fmt.Sprintf("[%x%v]%v/%d/*", fingerprint[:],
+		strings.TrimPrefix(accountPath.AccountPath(), "m"), accountPub,
+		branch)
+	desc := fmt.Sprintf(descriptorFmt, key)
+
+	checksum, err := DescriptorChecksum(desc)
+	if err != nil {
+		return "", err
+	}
+
+	return desc + "#" + checksum, nil
+}
+
+// BuildTaprootKeyDescriptor returns the checksummed output descriptor of a
+// single BIP0086 p2tr address, with its x-only internal key given explicitly
+// along with its origin, e.g. tr([fingerprint/86'/0'/0'/0/0]key)#checksum.
+// Just like for an extended key, Bitcoin Core applies the BIP0086 tweak to the
+// internal key to get the output key of the address.
+func BuildTaprootKeyDescriptor(fingerprint [4]byte, path KeyPath,
+	internalKey []byte) (string, error) {
+
+	if len(internalKey) != 32 {
+		return "", fmt.Errorf("expected a 32 byte x-only internal "+
+			"key, instead got %v bytes", len(internalKey))
+	}
+
+	desc := fmt.Sprintf("tr([%x%v]%x)", fingerprint[:],
+		strings.TrimPrefix(path.String(), "m"), internalKey)
+
+	checksum, err := DescriptorChecksum(desc)
+	if err != nil {
+		return "", err
+	}
+
+	return desc + "#" + checksum, nil
+}
+
+// DescriptorChecksum computes the 8 character checksum of the passed output
+// descriptor, as specified by Bitcoin Core.
+func DescriptorChecksum(desc string) (string, error) {
+	c := uint64(1)
+	class, classCount := 0, 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos == -1 {
+			return "", fmt.Errorf("invalid descriptor character %q",
+				ch)
+		}
+
+		// Emit a symbol for the position inside the group, for every
+		// character.
+		c = descriptorPolymod(c, pos&31)
+
+		// Accumulate the group numbers, and emit a symbol for every 3
+		// characters.
+		class = class*3 + pos>>5
+		classCount++
+		if classCount == 3 {
+			c = descriptorPolymod(c, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		c = descriptorPolymod(c, class)
+	}
+
+	// Shift further to determine the checksum.
+	for i := 0; i < 8; i++ {
+		c = descriptorPolymod(c, 0)
+	}
+
+	// Prevent appending zeroes from not affecting the checksum.
+	c ^= 1
+
+	var checksum [8]byte
+	for i := 0; i < 8; i++ {
+		checksum[i] = descriptorChecksumCharset[(c>>uint(5*(7-i)))&31]
+	}
+
+	return string(checksum[:]), nil
+}
+
+// descriptorPolymod feeds the passed value into the descriptor checksum
+// polynomial.
+func descriptorPolymod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ uint64(val)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+
+	return c
+}
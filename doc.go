@@ -0,0 +1,4 @@
+// Package aezeedcheck derives the keys and addresses of an lnd wallet from its
+// aezeed cipher seed, so that a seed can be verified, or its funds recovered,
+// without running lnd itself.
+package aezeedcheck
@@ -0,0 +1,22 @@
+package aezeedcheck
+
+import (
+	"crypto/sha256"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// NodeECDH returns the ECDH shared secret of the node private key and the
+// passed peer pubkey, which is the sha256 of the compressed shared point,
+// exactly like the ScalarMult method of lnd's key ring computes it.
+func NodeECDH(nodeKey *btcec.PrivateKey,
+	peerPub *btcec.PublicKey) [sha256.Size]byte {
+
+	var shared btcec.PublicKey
+	shared.Curve = btcec.S256()
+	shared.X, shared.Y = btcec.S256().ScalarMult(
+		peerPub.X, peerPub.Y, nodeKey.D.Bytes(),
+	)
+
+	return sha256.Sum256(shared.SerializeCompressed())
+}
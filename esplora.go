@@ -0,0 +1,269 @@
+package aezeedcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const (
+	// DefaultEsploraTimeout is the default timeout of a single request to
+	// an Esplora server.
+	DefaultEsploraTimeout = 30 * time.Second
+
+	// esploraRequestInterval is the minimum time between two requests to
+	// an Esplora server, so we don't hammer public servers when looking
+	// up many addresses.
+	esploraRequestInterval = 250 * time.Millisecond
+
+	// maxEsploraResponseSize is the maximum size of a response we'll read
+	// from an Esplora server.
+	maxEsploraResponseSize = 1 << 20
+)
+
+// AddressBalance is the confirmed on-chain history of an address, as reported
+// by an Esplora server.
+type AddressBalance struct {
+	// Confirmed is the confirmed balance of the address.
+	Confirmed btcutil.Amount
+
+	// TxCount is the number of confirmed transactions that involve the
+	// address.
+	TxCount uint64
+}
+
+// esploraChainStats is the part of the response of Esplora's address endpoint
+// that we're interested in.
+type esploraChainStats struct {
+	ChainStats struct {
+		FundedTxoSum int64  `json:"funded_txo_sum"`
+		SpentTxoSum  int64  `json:"spent_txo_sum"`
+		TxCount      uint64 `json:"tx_count"`
+	} `json:"chain_stats"`
+}
+
+// EsploraClient looks up the balances of addresses from the REST API of an
+// Esplora server, with a minimum interval between consecutive requests.
+type EsploraClient struct {
+	baseURL     string
+	client      *http.Client
+	lastRequest time.Time
+}
+
+// NewEsploraClient returns a client for the Esplora server at the passed base
+// URL, e.g. https://blockstream.info/api, with the given timeout for each
+// request. If a proxy is given, e.g. socks5://127.0.0.1:9050 for Tor, then all
+// requests are routed through it, including the resolution of the server's
+// host name.
+func NewEsploraClient(baseURL string, timeout time.Duration,
+	proxy *url.URL) *EsploraClient {
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &EsploraClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+	}
+}
+
+// newEsploraClient returns a client for the Esplora server of the passed
+// config. If a proxy is configured, then we'll make sure it's reachable
+// first, so a proxy that isn't running is reported as such, rather than as a
+// failure of the first request.
+func newEsploraClient(cfg *Config) (*EsploraClient, error) {
+	if cfg.Proxy == "" {
+		return NewEsploraClient(
+			cfg.Esplora, cfg.EsploraTimeout, nil,
+		), nil
+	}
+
+	proxy, err := parseProxyURL(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", proxy.Host, cfg.EsploraTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach proxy %v: %w",
+			proxy.Host, err)
+	}
+	conn.Close()
+
+	return NewEsploraClient(cfg.Esplora, cfg.EsploraTimeout, proxy), nil
+}
+
+// parseProxyURL parses the passed SOCKS5 proxy URL, e.g.
+// socks5://127.0.0.1:9050.
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if proxy.Scheme != "socks5" || proxy.Hostname() == "" ||
+		proxy.Port() == "" {
+
+		return nil, fmt.Errorf("invalid proxy URL %q, expected "+
+			"socks5://host:port", proxyURL)
+	}
+
+	return proxy, nil
+}
+
+// AddressBalance returns the confirmed balance of the passed address.
+func (c *EsploraClient) AddressBalance(
+	addr btcutil.Address) (*AddressBalance, error) {
+
+	wait := esploraRequestInterval - time.Since(c.lastRequest)
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	c.lastRequest = time.Now()
+
+	resp, err := c.client.Get(
+		c.baseURL + "/address/" + url.PathEscape(addr.EncodeAddress()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(
+		&io.LimitedReader{R: resp.Body, N: maxEsploraResponseSize},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v: %s",
+			resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var stats esploraChainStats
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, fmt.Errorf("unable to decode response: %w", err)
+	}
+
+	return &AddressBalance{
+		Confirmed: btcutil.Amount(
+			stats.ChainStats.FundedTxoSum -
+				stats.ChainStats.SpentTxoSum,
+		),
+		TxCount: stats.ChainStats.TxCount,
+	}, nil
+}
+
+// scanBranchAddrs derives the addresses of the given type from a branch key in
+// order, looking up each of them from the Esplora server, until gapLimit
+// consecutive unused addresses are found. Only the used addresses are
+// returned, along with their balances. The progress of the scan is reported
+// to the passed function, if any.
+func scanBranchAddrs(client *EsploraClient,
+	branchKey *hdkeychain.ExtendedKey, t addrType, gapLimit uint32,
+	params *chaincfg.Params, withWIF bool,
+	progress ProgressFunc) ([]derivedAddr, error) {
+
+	if progress == nil {
+		progress = func(string, uint64, uint64) {}
+	}
+	what := fmt.Sprintf("Scanning %v addresses", t.name)
+
+	var (
+		used   []derivedAddr
+		unused uint32
+		i      uint32
+	)
+	defer func() {
+		progress(what, uint64(i), uint64(i))
+	}()
+	for ; unused < gapLimit; i++ {
+		progress(what, uint64(i), 0)
+
+		if i >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("no gap of %v unused %v "+
+				"addresses found", gapLimit, t.name)
+		}
+
+		addr, wif, err := deriveAddr(branchKey, t, i, params, withWIF)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive %v addr at "+
+				"index %v: %w", t.name, i, err)
+		}
+
+		balance, err := client.AddressBalance(addr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to look up balance of "+
+				"%v: %w", addr, err)
+		}
+
+		// An address counts as used if it was ever part of a
+		// confirmed transaction, even if it was emptied since.
+		if balance.TxCount == 0 {
+			unused++
+			continue
+		}
+		unused = 0
+
+		used = append(used, derivedAddr{
+			addrType: t,
+			path:     KeyPath{Index: i},
+			addr:     addr,
+			wif:      wif,
+			balance:  balance,
+		})
+	}
+
+	return used, nil
+}
+
+// validateEsploraURL checks that the passed Esplora base URL is an absolute
+// HTTP(S) URL.
+func validateEsploraURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid Esplora URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid Esplora URL %q, expected an "+
+			"http:// or https:// URL", baseURL)
+	}
+
+	return nil
+}
+
+// lookupBalances looks up the balance of each of the passed addresses from
+// the configured Esplora server.
+func lookupBalances(cfg *Config, addrs []derivedAddr) error {
+	client, err := newEsploraClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	for i := range addrs {
+		balance, err := client.AddressBalance(addrs[i].addr)
+		if err != nil {
+			return fmt.Errorf("unable to look up balance of %v: %w",
+				addrs[i].addr, err)
+		}
+		addrs[i].balance = balance
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// AddrMatch is a derived address that matches the one searched for.
+type AddrMatch struct {
+	// AddrType is the name of the type of the address, e.g. p2wkh.
+	AddrType string
+
+	// Path is the derivation path of the key of the address.
+	Path KeyPath
+}
+
+// FindAddr searches the addresses that Run would derive from the seed with the
+// passed config for the target address: Count addresses starting at
+// FirstIndex on each selected branch, of each selected address type and
+// account. The search stops at the first match, so not all of them may be
+// derived, and the types whose addresses are of a different kind than the
+// target are skipped. If the target isn't found, nil is returned.
+func FindAddr(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	target btcutil.Address) (*AddrMatch, error) {
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	keyCache := newAccountKeyCache(rootKey, params.CoinType)
+	keyCache.cfg = cfg
+	defer keyCache.zero()
+
+	types := selectedAddrTypes(cfg)
+	branches := branchSelections[cfg.Branch]
+	accounts := cfg.accounts()
+	total := uint64(len(accounts)) * uint64(len(types)) *
+		uint64(len(branches)) * uint64(cfg.Count)
+
+	var done uint64
+	for _, account := range accounts {
+		for _, t := range types {
+			accountKey, err := keyCache.accountKey(
+				t.purpose, keychain.KeyFamily(account),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("unable to derive %v "+
+					"account key: %w", t.name, err)
+			}
+
+			for _, b := range branches {
+				path := KeyPath{
+					Purpose:  t.purpose,
+					CoinType: params.CoinType,
+					Account:  account,
+					Branch:   b,
+				}
+				found, err := searchBranchAddrs(
+					cfg, accountKey, t, path, target,
+				)
+				if err != nil || found != nil {
+					return found, err
+				}
+
+				done += uint64(cfg.Count)
+				if cfg.Progress != nil {
+					cfg.Progress(
+						"Searching addresses", done,
+						total,
+					)
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// searchBranchAddrs searches the Count addresses of the given type starting at
+// FirstIndex on the branch of the passed account key that's identified by the
+// branch of path, for the target address. If the first address is of a
+// different kind than the target, then none of the others can match, so the
+// search ends right away.
+func searchBranchAddrs(cfg *Config, accountKey *hdkeychain.ExtendedKey,
+	t addrType, path KeyPath, target btcutil.Address) (*AddrMatch, error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	branchKey, err := accountKey.Child(path.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive %v branch key: %w",
+			t.name, err)
+	}
+	defer branchKey.Zero()
+
+	for i := uint32(0); i < cfg.Count; i++ {
+		path.Index = cfg.FirstIndex + i
+		addr, _, err := deriveAddr(
+			branchKey, t, path.Index, params.Params, false,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive %v addr at "+
+				"index %v: %w", t.name, path.Index, err)
+		}
+
+		switch {
+		case reflect.TypeOf(addr) != reflect.TypeOf(target):
+			return nil, nil
+
+		case addr.EncodeAddress() == target.EncodeAddress():
+			return &AddrMatch{AddrType: t.name, Path: path}, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,150 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// MaxFundingIndexes is the most indexes of the multisig key family that
+// DeriveFundingCandidates searches. A node would need to open as many channels
+// to get past it.
+const MaxFundingIndexes = 100000
+
+// FundingCandidate is a possible funding output of a channel, made up of one
+// of our multisig keys and the remote multisig key of the channel.
+type FundingCandidate struct {
+	// Path is the path our multisig key was derived at.
+	Path KeyPath
+
+	// LocalKey is our multisig key.
+	LocalKey *btcec.PublicKey
+
+	// Addr is the P2WSH address of the 2-of-2 funding output.
+	Addr btcutil.Address
+}
+
+// DeriveFundingCandidates derives our keys at the first numIndexes indexes of
+// lnd's multisig key family, and the P2WSH address of the 2-of-2 funding
+// output that each of them forms with the passed remote multisig key. As each
+// channel uses the next index of the family, this locates the funding output
+// of a channel whose peer is known.
+func DeriveFundingCandidates(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	remoteKey *btcec.PublicKey,
+	numIndexes uint32) ([]FundingCandidate, error) {
+
+	if numIndexes > MaxFundingIndexes {
+		return nil, fmt.Errorf("at most %v indexes can be derived",
+			MaxFundingIndexes)
+	}
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	keyCache := newAccountKeyCache(rootKey, params.CoinType)
+	defer keyCache.zero()
+
+	accountKey, err := keyCache.accountKey(
+		keychain.BIP0043Purpose, keychain.KeyFamilyMultiSig,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive multisig account "+
+			"key: %w", err)
+	}
+	branchKey, err := accountKey.Child(ExternalBranch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive multisig branch "+
+			"key: %w", err)
+	}
+	defer branchKey.Zero()
+
+	var candidates []FundingCandidate
+	for i := uint32(0); i < numIndexes; i++ {
+		child, err := branchKey.Child(i)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive multisig key "+
+				"at index %v: %w", i, err)
+		}
+		localKey, err := child.ECPubKey()
+		child.Zero()
+		if err != nil {
+			return nil, err
+		}
+
+		script, err := sortedMultiSigScript(
+			2, []*btcec.PublicKey{localKey, remoteKey},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build funding "+
+				"script: %w", err)
+		}
+		addr, err := p2wshAddr(script, params)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, FundingCandidate{
+			Path: KeyPath{
+				Purpose:  keychain.BIP0043Purpose,
+				CoinType: params.CoinType,
+				Account:  uint32(keychain.KeyFamilyMultiSig),
+				Branch:   ExternalBranch,
+				Index:    i,
+			},
+			LocalKey: localKey,
+			Addr:     addr,
+		})
+	}
+
+	return candidates, nil
+}
+
+// sortedMultiSigScript builds the bare multisig script that requires the
+// given number of signatures of the passed keys. The compressed keys are
+// sorted lexicographically first, as required by both BIP0067 and the funding
+// script of BOLT #3.
+func sortedMultiSigScript(required int,
+	keys []*btcec.PublicKey) ([]byte, error) {
+
+	serialized := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		serialized = append(serialized, key.SerializeCompressed())
+	}
+	sort.Slice(serialized, func(i, j int) bool {
+		return bytes.Compare(serialized[i], serialized[j]) < 0
+	})
+
+	builder := txscript.NewScriptBuilder().AddInt64(int64(required))
+	for _, key := range serialized {
+		builder.AddData(key)
+	}
+	builder.AddInt64(int64(len(keys))).AddOp(txscript.OP_CHECKMULTISIG)
+
+	return builder.Script()
+}
+
+// p2wshAddr returns the P2WSH address that pays to the passed witness script.
+func p2wshAddr(witnessScript []byte, params *NetParams) (btcutil.Address,
+	error) {
+
+	scriptHash := sha256.Sum256(witnessScript)
+	return btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], params.Params,
+	)
+}
@@ -1,11 +1,12 @@
 module github.com/lightninglabs/aezeedcheck
 
-go 1.12
+go 1.13
 
 require (
 	github.com/btcsuite/btcd v0.0.0-20190629003639-c26ffa870fd8
 	github.com/btcsuite/btcutil v0.0.0-20190425235716-9e5f4b9a998d
 	github.com/btcsuite/btcwallet v0.0.0-20190628225330-4a9774585e57
+	github.com/btcsuite/golangcrypto v0.0.0-20150304025918-53f62d9b43e8
 
 	github.com/lightningnetwork/lnd v0.7.0-beta
 )
@@ -0,0 +1,136 @@
+package aezeedcheck
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// accountKeyID identifies an account key by the purpose of its key scope and
+// its account, which is the key family in the case of lnd.
+type accountKeyID struct {
+	purpose uint32
+	family  keychain.KeyFamily
+}
+
+// accountKeyCache caches the hardened keys derived from a root key, so that
+// each account path is only derived once, no matter how many keys are derived
+// from it. The cache holds private key material, so it should only live for
+// the duration of a single run.
+type accountKeyCache struct {
+	rootKey  *hdkeychain.ExtendedKey
+	coinType uint32
+
+	// cfg is the optional config that each derivation step is logged
+	// with.
+	cfg *Config
+
+	// coinTypeKeys holds the coin type key of each purpose, which is
+	// shared by all accounts of the purpose.
+	coinTypeKeys map[uint32]*hdkeychain.ExtendedKey
+
+	// accountKeys holds the account keys derived so far.
+	accountKeys map[accountKeyID]*hdkeychain.ExtendedKey
+}
+
+// newAccountKeyCache returns an empty cache of the account keys derived from
+// the passed root key for the given coin type.
+func newAccountKeyCache(rootKey *hdkeychain.ExtendedKey,
+	coinType uint32) *accountKeyCache {
+
+	return &accountKeyCache{
+		rootKey:      rootKey,
+		coinType:     coinType,
+		coinTypeKeys: make(map[uint32]*hdkeychain.ExtendedKey),
+		accountKeys:  make(map[accountKeyID]*hdkeychain.ExtendedKey),
+	}
+}
+
+// accountKey returns the hardened account key at the path
+// m/purpose'/coinType'/keyFamily', deriving it only if it's not cached yet.
+func (c *accountKeyCache) accountKey(purpose uint32,
+	keyFamily keychain.KeyFamily) (*hdkeychain.ExtendedKey, error) {
+
+	id := accountKeyID{purpose: purpose, family: keyFamily}
+	if accountKey, ok := c.accountKeys[id]; ok {
+		return accountKey, nil
+	}
+
+	coinTypeKey, ok := c.coinTypeKeys[purpose]
+	if !ok {
+		c.cfg.logf(VerbositySteps, "Deriving purpose key m/%d'",
+			purpose)
+		purposeKey, err := c.rootKey.Child(
+			purpose + hdkeychain.HardenedKeyStart,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive purpose key; "+
+				"%w", err)
+		}
+		c.cfg.logFingerprint(fmt.Sprintf("m/%d'", purpose), purposeKey)
+
+		c.cfg.logf(VerbositySteps, "Deriving coin type key m/%d'/%d'",
+			purpose, c.coinType)
+		coinTypeKey, err = purposeKey.Child(
+			c.coinType + hdkeychain.HardenedKeyStart,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate coin type "+
+				"key: %w", err)
+		}
+		purposeKey.Zero()
+		c.cfg.logFingerprint(
+			fmt.Sprintf("m/%d'/%d'", purpose, c.coinType),
+			coinTypeKey,
+		)
+		c.coinTypeKeys[purpose] = coinTypeKey
+	}
+
+	path := KeyPath{
+		Purpose:  purpose,
+		CoinType: c.coinType,
+		Account:  uint32(keyFamily),
+	}
+	c.cfg.logf(VerbositySteps, "Deriving account key %v",
+		path.AccountPath())
+	accountKey, err := coinTypeKey.Child(
+		uint32(keyFamily) + hdkeychain.HardenedKeyStart,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive account key: %w", err)
+	}
+	c.cfg.logFingerprint(path.AccountPath(), accountKey)
+	c.accountKeys[id] = accountKey
+
+	return accountKey, nil
+}
+
+// firstKey returns the first key of the external branch of the account
+// identified by the given purpose and key family.
+func (c *accountKeyCache) firstKey(purpose uint32,
+	keyFamily keychain.KeyFamily) (*btcec.PublicKey, error) {
+
+	accountKey, err := c.accountKey(purpose, keyFamily)
+	if err != nil {
+		return nil, err
+	}
+
+	return DeriveKeyAtIndex(accountKey, ExternalBranch, 0)
+}
+
+// zero clears the root key and all keys cached so far, after which the cache
+// must no longer be used.
+func (c *accountKeyCache) zero() {
+	for _, key := range c.accountKeys {
+		key.Zero()
+	}
+	for _, key := range c.coinTypeKeys {
+		key.Zero()
+	}
+	c.rootKey.Zero()
+
+	c.accountKeys = nil
+	c.coinTypeKeys = nil
+}
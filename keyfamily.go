@@ -0,0 +1,47 @@
+package aezeedcheck
+
+import (
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// KeyFamilies is the set of key families lnd derives keys from under the
+// keychain.BIP0043Purpose, in ascending order.
+var KeyFamilies = []keychain.KeyFamily{
+	keychain.KeyFamilyMultiSig,
+	keychain.KeyFamilyRevocationBase,
+	keychain.KeyFamilyHtlcBase,
+	keychain.KeyFamilyPaymentBase,
+	keychain.KeyFamilyDelayBase,
+	keychain.KeyFamilyRevocationRoot,
+	keychain.KeyFamilyNodeKey,
+	keychain.KeyFamilyStaticBackup,
+	keychain.KeyFamilyTowerSession,
+	keychain.KeyFamilyTowerID,
+}
+
+// keyFamilyNames maps each of the known key families to a human readable name.
+var keyFamilyNames = map[keychain.KeyFamily]string{
+	keychain.KeyFamilyMultiSig:       "multisig",
+	keychain.KeyFamilyRevocationBase: "revocation base",
+	keychain.KeyFamilyHtlcBase:       "htlc base",
+	keychain.KeyFamilyPaymentBase:    "payment base",
+	keychain.KeyFamilyDelayBase:      "delay base",
+	keychain.KeyFamilyRevocationRoot: "revocation root",
+	keychain.KeyFamilyNodeKey:        "node key",
+	keychain.KeyFamilyStaticBackup:   "static backup",
+	keychain.KeyFamilyTowerSession:   "tower session",
+	keychain.KeyFamilyTowerID:        "tower id",
+}
+
+// KeyFamilyName returns the human readable name of the passed key family, or
+// a generic name containing its number if the family isn't known.
+func KeyFamilyName(family keychain.KeyFamily) string {
+	name, ok := keyFamilyNames[family]
+	if !ok {
+		return fmt.Sprintf("family %d", family)
+	}
+
+	return name
+}
@@ -0,0 +1,61 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const (
+	// VerbositySteps logs each derivation step of a recovery, from the
+	// purpose key down to the child indexes of each branch.
+	VerbositySteps = 1
+
+	// VerbosityFingerprints additionally logs each derived child key, and
+	// the fingerprint of the key at each depth of its path.
+	VerbosityFingerprints = 2
+)
+
+// logf logs the passed message to the standard logger if the verbosity of the
+// config is at least the given level. It's safe to call on a nil config, which
+// logs nothing. No secret key material must ever be passed to it.
+func (c *Config) logf(level uint8, format string, args ...interface{}) {
+	if c == nil || c.Verbosity < level {
+		return
+	}
+
+	log.Printf(format, args...)
+}
+
+// warnf reports the passed warning through the Warn callback of the config if
+// it's set, or logs it to the standard logger otherwise. Unlike logf, warnings
+// are reported at any verbosity.
+func (c *Config) warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if c.Warn != nil {
+		c.Warn(msg)
+		return
+	}
+
+	log.Printf("WARNING: %v", msg)
+}
+
+// logFingerprint logs the fingerprint of the passed key, derived at the given
+// path, if the verbosity of the config is at least VerbosityFingerprints.
+//
+// NOTE: Computing the fingerprint of a private key memoizes its public key, so
+// this must not race with a concurrent child derivation from the same key.
+func (c *Config) logFingerprint(path string, key *hdkeychain.ExtendedKey) {
+	if c == nil || c.Verbosity < VerbosityFingerprints {
+		return
+	}
+
+	fingerprint, err := MasterFingerprint(key)
+	if err != nil {
+		log.Printf("Unable to compute fingerprint of %v: %v", path, err)
+		return
+	}
+
+	log.Printf("Fingerprint of %v: %x", path, fingerprint[:])
+}
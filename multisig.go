@@ -0,0 +1,271 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+const (
+	// BIP0048Purpose is the purpose of the multisig key scope of BIP0048.
+	BIP0048Purpose = 48
+
+	// bip48ScriptTypeP2WSH is the hardened BIP0048 script type level of
+	// native segwit multisig accounts.
+	bip48ScriptTypeP2WSH = 2
+
+	// MaxMultisigKeys is the maximum number of keys of a sortedmulti
+	// descriptor within wsh, as enforced by Bitcoin Core.
+	MaxMultisigKeys = 20
+)
+
+var (
+	// slip132MultisigVersion holds the SLIP-0132 version bytes of the
+	// Zpub/Vpub account extended public keys of P2WSH multisig wallets.
+	slip132MultisigVersion = slip132Version{
+		mainnet: [4]byte{0x02, 0xaa, 0x7e, 0xd3},
+		testnet: [4]byte{0x02, 0x57, 0x54, 0x83},
+	}
+)
+
+// MultisigAddr is a single address of a multisig wallet.
+type MultisigAddr struct {
+	// Branch is the branch of the accounts the keys of the address were
+	// derived from.
+	Branch uint32
+
+	// Index is the index of the keys within the branch.
+	Index uint32
+
+	// Addr is the P2WSH address of the sorted multisig script.
+	Addr btcutil.Address
+}
+
+// MultisigWallet is the share of a P2WSH multisig wallet that's derived from
+// the seed, along with the descriptors and addresses of the whole wallet.
+type MultisigWallet struct {
+	// AccountPath is the BIP0048 derivation path of our account key.
+	AccountPath string
+
+	// Xpub is our account extended public key, which is shared with the
+	// cosigners.
+	Xpub string
+
+	// Descriptors holds the wsh(sortedmulti(...)) descriptor of the
+	// external branch, followed by the one of the internal branch.
+	Descriptors []string
+
+	// Addrs holds the addresses derived on each configured branch.
+	Addrs []MultisigAddr
+}
+
+// DeriveMultisig derives our BIP0048 account key of a P2WSH multisig wallet
+// that requires the given number of signatures of our key and those of the
+// passed cosigner account xpubs, along with the Count addresses on each
+// configured branch of the wallet. The keys of each address are sorted as
+// specified by BIP0067, so the addresses match those of other wallets.
+func DeriveMultisig(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	required int, cosignerXpubs []string) (*MultisigWallet, error) {
+
+	numKeys := len(cosignerXpubs) + 1
+	if required < 1 || required > numKeys {
+		return nil, fmt.Errorf("the number of required signatures "+
+			"must be between 1 and %v", numKeys)
+	}
+	if numKeys > MaxMultisigKeys {
+		return nil, fmt.Errorf("a multisig wallet can have at most "+
+			"%v keys", MaxMultisigKeys)
+	}
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	keyCache := newAccountKeyCache(rootKey, params.CoinType)
+	defer keyCache.zero()
+
+	fingerprint, err := MasterFingerprint(rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute master "+
+			"fingerprint: %w", err)
+	}
+
+	// BIP0048 adds a script type level below the account, so our account
+	// key is one more hardened level below the one of the key cache.
+	bip48Account, err := keyCache.accountKey(BIP0048Purpose, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive multisig account "+
+			"key: %w", err)
+	}
+	accountKey, err := bip48Account.Child(
+		bip48ScriptTypeP2WSH + hdkeychain.HardenedKeyStart,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive multisig script "+
+			"type key: %w", err)
+	}
+	defer accountKey.Zero()
+
+	accountPub, err := accountKey.Neuter()
+	if err != nil {
+		return nil, fmt.Errorf("unable to neuter multisig account "+
+			"key: %w", err)
+	}
+
+	// Our own key comes first, followed by the cosigners in the order
+	// they were given, which doesn't matter for sorted multisig.
+	accountKeys := []*hdkeychain.ExtendedKey{accountPub}
+	for i, xpub := range cosignerXpubs {
+		key, err := parseCosignerXpub(xpub, params.Params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cosigner xpub #%v: %w",
+				i+1, err)
+		}
+		for _, other := range accountKeys {
+			if other.String() == key.String() {
+				return nil, fmt.Errorf("cosigner xpub #%v is "+
+					"used more than once", i+1)
+			}
+		}
+		accountKeys = append(accountKeys, key)
+	}
+
+	accountPath := fmt.Sprintf("m/%d'/%d'/0'/%d'", BIP0048Purpose,
+		params.CoinType, bip48ScriptTypeP2WSH)
+	wallet := &MultisigWallet{
+		AccountPath: accountPath,
+		Xpub:        accountPub.String(),
+	}
+
+	for _, b := range []uint32{ExternalBranch, InternalBranch} {
+		keyExprs := []string{fmt.Sprintf("[%x%v]%v/%d/*",
+			fingerprint[:], strings.TrimPrefix(accountPath, "m"),
+			accountPub, b)}
+		for _, key := range accountKeys[1:] {
+			keyExprs = append(
+				keyExprs, fmt.Sprintf("%v/%d/*", key, b),
+			)
+		}
+
+		desc := fmt.Sprintf("wsh(sortedmulti(%d,%v))", required,
+			strings.Join(keyExprs, ","))
+		checksum, err := DescriptorChecksum(desc)
+		if err != nil {
+			return nil, err
+		}
+		wallet.Descriptors = append(
+			wallet.Descriptors, desc+"#"+checksum,
+		)
+	}
+
+	for _, b := range branchSelections[cfg.Branch] {
+		branchKeys := make([]*hdkeychain.ExtendedKey, len(accountKeys))
+		for i, key := range accountKeys {
+			branchKeys[i], err = key.Child(b)
+			if err != nil {
+				return nil, fmt.Errorf("unable to derive "+
+					"branch key: %w", err)
+			}
+		}
+
+		lastIndex := cfg.FirstIndex + cfg.Count - 1
+		for index := cfg.FirstIndex; index <= lastIndex; index++ {
+			keys := make([]*btcec.PublicKey, len(branchKeys))
+			for i, branchKey := range branchKeys {
+				child, err := branchKey.Child(index)
+				if err != nil {
+					return nil, fmt.Errorf("unable to "+
+						"derive key at index %v: %w",
+						index, err)
+				}
+				keys[i], err = child.ECPubKey()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			script, err := sortedMultiSigScript(required, keys)
+			if err != nil {
+				return nil, fmt.Errorf("unable to build "+
+					"multisig script: %w", err)
+			}
+			addr, err := p2wshAddr(script, params)
+			if err != nil {
+				return nil, err
+			}
+
+			wallet.Addrs = append(wallet.Addrs, MultisigAddr{
+				Branch: b,
+				Index:  index,
+				Addr:   addr,
+			})
+		}
+	}
+
+	return wallet, nil
+}
+
+// parseCosignerXpub parses the account extended public key of a cosigner,
+// which may be encoded with either the standard or the SLIP-0132 Zpub/Vpub
+// version bytes of the passed network.
+func parseCosignerXpub(xpub string,
+	params *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+
+	decoded := base58.Decode(xpub)
+	if len(decoded) != serializedKeyLen+checksumLen {
+		return nil, fmt.Errorf("invalid extended key length: %v",
+			len(decoded))
+	}
+
+	// A SLIP-0132 key is re-serialized with the standard version bytes,
+	// which also requires recomputing its checksum. The checksum of the
+	// original serialization is verified first, so that a mistyped key
+	// isn't silently accepted.
+	version := slip132MultisigVersion.testnet
+	mainnetID := chaincfg.MainNetParams.HDPublicKeyID
+	if bytes.Equal(params.HDPublicKeyID[:], mainnetID[:]) {
+		version = slip132MultisigVersion.mainnet
+	}
+	if bytes.Equal(decoded[:4], version[:]) {
+		payload := decoded[:serializedKeyLen]
+		checksum := chainhash.DoubleHashB(payload)[:checksumLen]
+		if !bytes.Equal(checksum, decoded[serializedKeyLen:]) {
+			return nil, hdkeychain.ErrBadChecksum
+		}
+
+		copy(payload[:4], params.HDPublicKeyID[:])
+		checksum = chainhash.DoubleHashB(payload)[:checksumLen]
+		xpub = base58.Encode(append(payload, checksum...))
+	}
+
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsPrivate() {
+		return nil, errors.New("expected an extended public key, not " +
+			"an extended private key")
+	}
+	if !key.IsForNet(params) {
+		return nil, fmt.Errorf("extended public key doesn't belong "+
+			"to %v", params.Name)
+	}
+
+	return key, nil
+}
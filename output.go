@@ -0,0 +1,1189 @@
+package aezeedcheck
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// JSONSchemaVersion is the version of the schema of the JSON format,
+	// which is included in its output as schemaVersion. Fields may be
+	// added within a version, but it's bumped whenever a field is renamed,
+	// removed or changes its meaning, so parsers can branch on it.
+	JSONSchemaVersion = 1
+
+	// electrumSeedVersion is the version of the Electrum wallet file
+	// format that printElectrum writes, which newer versions of Electrum
+	// upgrade when opening the file.
+	electrumSeedVersion = 18
+)
+
+// outputDescriptor is the output descriptor of one branch of an account.
+type outputDescriptor struct {
+	// addrType is the address type of the account.
+	addrType addrType
+
+	// branch is the branch of the account the descriptor describes.
+	branch uint32
+
+	// desc is the output descriptor, including its checksum.
+	desc string
+}
+
+// derivedAddr is a single address derived from the seed.
+type derivedAddr struct {
+	// addrType is the type of the address.
+	addrType addrType
+
+	// path is the path the key of the address was derived at.
+	path KeyPath
+
+	// addr is the derived address.
+	addr btcutil.Address
+
+	// wif is the WIF encoded private key of the address, if requested.
+	wif string
+
+	// internalKey is the x-only BIP0086 internal key of a p2tr address,
+	// if descriptors were requested.
+	internalKey []byte
+
+	// balance is the balance of the address looked up from an Esplora
+	// server, if requested.
+	balance *AddressBalance
+}
+
+// skippedBackup is a channel backup file that was skipped, as it couldn't be
+// decrypted.
+type skippedBackup struct {
+	// path is the path of the file.
+	path string
+
+	// err is the reason the file was skipped.
+	err error
+}
+
+// accountXpub holds the extended keys of one of the derived accounts.
+type accountXpub struct {
+	// addrType is the address type the account is used for.
+	addrType addrType
+
+	// path is the path of the account. Only the account level elements
+	// are populated.
+	path KeyPath
+
+	// xpub is the serialized extended public key, if requested.
+	xpub string
+
+	// xprv is the serialized extended private key, if requested.
+	xprv string
+}
+
+// familyKey is the first key of one of lnd's key families.
+type familyKey struct {
+	// family is the key family the key belongs to.
+	family keychain.KeyFamily
+
+	// path is the path the key was derived at.
+	path KeyPath
+
+	// pub is the derived public key.
+	pub *btcec.PublicKey
+}
+
+// locatorKey is a single key identified by a key locator.
+type locatorKey struct {
+	// loc is the key locator of the key.
+	loc keychain.KeyLocator
+
+	// path is the path the key was derived at.
+	path KeyPath
+
+	// pub is the derived public key.
+	pub *btcec.PublicKey
+
+	// priv is the derived private key, if requested.
+	priv *btcec.PrivateKey
+}
+
+// recoveryResult holds everything that was recovered from the seed, ready to
+// be printed in any of the supported output formats.
+type recoveryResult struct {
+	// mnemonic is the mnemonic of the seed, if it should be printed.
+	mnemonic *aezeed.Mnemonic
+
+	// mnemonicVersion is the version encoded by the mnemonic.
+	mnemonicVersion uint8
+
+	// birthday is the birthday of the seed.
+	birthday time.Time
+
+	// birthdayHeight is the estimated height of the Bitcoin block that was
+	// mined on the birthday.
+	birthdayHeight uint32
+
+	// entropy is the raw entropy of the seed, if requested.
+	entropy []byte
+
+	// bip39 is the BIP0039 mnemonic encoding the entropy, if requested.
+	bip39 []string
+
+	// internalVersion is the internal version of the cipher seed.
+	internalVersion uint8
+
+	// fingerprint is the BIP0032 fingerprint of the master key, which
+	// identifies the seed.
+	fingerprint [4]byte
+
+	// nodePub is the lnd node identity key.
+	nodePub *btcec.PublicKey
+
+	// nodePath is the path the node identity key was derived at.
+	nodePath KeyPath
+
+	// familyKeys is the first key of each key family, if requested.
+	familyKeys []familyKey
+
+	// watchAccount is the account xpub the addresses were derived from in
+	// watch-only mode, in which case nothing is known about the seed.
+	watchAccount *accountXpub
+
+	// locatorKey is the key identified by the key locator, if requested.
+	locatorKey *locatorKey
+
+	// towerSessionKeys is the set of session keys of the watchtower
+	// client, if requested.
+	towerSessionKeys []*locatorKey
+
+	// towerIDKey is the identity key of the watchtower, if requested.
+	towerIDKey *locatorKey
+
+	// channelBackups is the set of channels contained in the static
+	// channel backup, if one was given.
+	channelBackups []ChannelBackup
+
+	// numBackupFiles is the number of channel backup files that were
+	// decrypted.
+	numBackupFiles int
+
+	// skippedBackups is the set of channel backup files found in the
+	// configured directory that couldn't be decrypted.
+	skippedBackups []skippedBackup
+
+	// addrs is the set of derived addresses, ordered by branch, then
+	// address type, then index.
+	addrs []derivedAddr
+
+	// electrumAccount is the BIP0084 account, with its extended public
+	// key SLIP-0132 encoded, if the Electrum format was requested.
+	electrumAccount *accountXpub
+
+	// xpubs is the set of account extended keys, if requested.
+	xpubs []accountXpub
+
+	// descriptors is the set of account output descriptors, if requested.
+	descriptors []outputDescriptor
+
+	// numAddrs is the number of addresses derived for each address type
+	// on each branch.
+	numAddrs uint32
+}
+
+// zero clears the secrets held by the result that can be cleared, once it has
+// been printed. Any encoded secret, like a WIF string, is immutable and will
+// linger until it's garbage collected.
+func (r *recoveryResult) zero() {
+	ZeroBytes(r.entropy)
+	if r.locatorKey != nil && r.locatorKey.priv != nil {
+		ZeroPrivKey(r.locatorKey.priv)
+	}
+}
+
+// jsonResult is the JSON representation of a recoveryResult. The struct is
+// defined explicitly, rather than marshalling the recoveryResult itself, to
+// keep the schema stable. It makes up version JSONSchemaVersion of the
+// schema, so the names and meanings of its fields must not change without
+// bumping the version.
+type jsonResult struct {
+	// SchemaVersion is always JSONSchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+
+	// Mnemonic holds the words of a newly generated seed.
+	Mnemonic []string `json:"mnemonic,omitempty"`
+
+	// MnemonicVersion is the version encoded by the mnemonic.
+	MnemonicVersion uint8 `json:"mnemonicVersion"`
+
+	// Birthday is the birthday of the seed as an RFC3339 timestamp.
+	Birthday string `json:"birthday"`
+
+	// BirthdayHeight is the estimated height of the Bitcoin block that
+	// was mined on the birthday.
+	BirthdayHeight uint32 `json:"birthdayHeightEstimate"`
+
+	// InternalVersion is the internal version of the cipher seed.
+	InternalVersion uint8 `json:"internalVersion"`
+
+	// Entropy is the hex-encoded entropy of the seed, if requested.
+	Entropy string `json:"entropy,omitempty"`
+
+	// BIP39Mnemonic holds the words of the BIP0039 mnemonic encoding the
+	// entropy, if requested.
+	BIP39Mnemonic []string `json:"bip39Mnemonic,omitempty"`
+
+	// Fingerprint is the hex-encoded BIP0032 fingerprint of the master
+	// key.
+	Fingerprint string `json:"masterFingerprint"`
+
+	// NodePubKey is the hex-encoded compressed lnd node identity key.
+	NodePubKey string `json:"nodePubKey"`
+
+	// NodePubKeyUncompressed is the hex-encoded uncompressed lnd node
+	// identity key, if requested.
+	NodePubKeyUncompressed string `json:"nodePubKeyUncompressed,omitempty"`
+
+	// KeyFamilies holds the first key of each of lnd's key families, if
+	// requested.
+	KeyFamilies []jsonFamilyKey `json:"keyFamilies,omitempty"`
+
+	// KeyLocator is the key identified by the requested key locator.
+	KeyLocator *jsonLocatorKey `json:"keyLocator,omitempty"`
+
+	// TowerSessionKeys holds the session keys of the watchtower client,
+	// if requested.
+	TowerSessionKeys []*jsonLocatorKey `json:"towerSessionKeys,omitempty"`
+
+	// TowerIDKey is the identity key of the watchtower, if requested.
+	TowerIDKey *jsonLocatorKey `json:"towerIdKey,omitempty"`
+
+	// ChannelBackups holds the channels of the decrypted static channel
+	// backups.
+	ChannelBackups []jsonChannel `json:"channelBackups,omitempty"`
+
+	// SkippedBackups holds the channel backup files that couldn't be
+	// decrypted.
+	SkippedBackups []jsonSkipped `json:"skippedBackups,omitempty"`
+
+	// Addresses holds the derived addresses, ordered by branch and then
+	// by address type.
+	Addresses []jsonAddr `json:"addresses"`
+
+	// AccountXpubs holds the extended keys of each account, if
+	// requested.
+	AccountXpubs []jsonXpub `json:"accountXpubs,omitempty"`
+
+	// Descriptors holds the output descriptors of each account, if
+	// requested.
+	Descriptors []jsonDesc `json:"descriptors,omitempty"`
+}
+
+// jsonWatchResult is the JSON representation of the addresses derived from an
+// account xpub in watch-only mode. It's versioned along with jsonResult.
+type jsonWatchResult struct {
+	// SchemaVersion is always JSONSchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+
+	// AccountXpub is the account xpub the addresses were derived from.
+	AccountXpub jsonXpub `json:"accountXpub"`
+
+	// Addresses holds the derived addresses.
+	Addresses []jsonAddr `json:"addresses"`
+}
+
+// jsonFamilyKey is the JSON representation of the first key of a key family.
+type jsonFamilyKey struct {
+	Family             uint32 `json:"family"`
+	Name               string `json:"name"`
+	Path               string `json:"path"`
+	PubKey             string `json:"pubKey"`
+	PubKeyUncompressed string `json:"pubKeyUncompressed,omitempty"`
+}
+
+// jsonLocatorKey is the JSON representation of the key identified by a key
+// locator.
+type jsonLocatorKey struct {
+	Family             uint32 `json:"family"`
+	Index              uint32 `json:"index"`
+	Path               string `json:"path"`
+	PubKey             string `json:"pubKey"`
+	PubKeyUncompressed string `json:"pubKeyUncompressed,omitempty"`
+	PrivKey            string `json:"privKey,omitempty"`
+}
+
+// jsonChannel is the JSON representation of a channel contained in a static
+// channel backup.
+type jsonChannel struct {
+	ChannelPoint   string `json:"channelPoint"`
+	ShortChannelID uint64 `json:"shortChannelID"`
+	RemoteNodePub  string `json:"remoteNodePub"`
+	IsInitiator    bool   `json:"isInitiator"`
+	Version        uint8  `json:"version"`
+}
+
+// jsonSkipped is the JSON representation of a skipped channel backup file.
+type jsonSkipped struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// jsonDesc is the JSON representation of an output descriptor.
+type jsonDesc struct {
+	Scope      string `json:"scope"`
+	Internal   bool   `json:"internal"`
+	Descriptor string `json:"descriptor"`
+}
+
+// jsonElectrumWallet is a minimal Electrum wallet file of a watch-only wallet
+// with a single BIP0032 keystore. Electrum fills in everything else, like the
+// address history, when opening it.
+type jsonElectrumWallet struct {
+	Keystore      jsonElectrumKeystore `json:"keystore"`
+	WalletType    string               `json:"wallet_type"`
+	SeedVersion   int                  `json:"seed_version"`
+	UseEncryption bool                 `json:"use_encryption"`
+}
+
+// jsonElectrumKeystore is the keystore of an Electrum wallet file. Electrum
+// detects the script type from the version bytes of the xpub, so there's no
+// field for it.
+type jsonElectrumKeystore struct {
+	Type            string  `json:"type"`
+	Xpub            string  `json:"xpub"`
+	Xprv            *string `json:"xprv"`
+	Derivation      string  `json:"derivation"`
+	RootFingerprint string  `json:"root_fingerprint"`
+}
+
+// jsonSparrowWallet is a wallet file in the Specter Desktop format, which
+// Sparrow imports as a watch-only wallet. The descriptor of the external branch
+// includes the key origin, from which Sparrow derives the master fingerprint,
+// the derivation path and the script type of its keystore.
+type jsonSparrowWallet struct {
+	Label       string `json:"label"`
+	BlockHeight uint32 `json:"blockheight,omitempty"`
+	Descriptor  string `json:"descriptor"`
+}
+
+// jsonImportDesc is a single request of bitcoind's importdescriptors RPC.
+type jsonImportDesc struct {
+	Desc      string    `json:"desc"`
+	Timestamp int64     `json:"timestamp"`
+	Active    bool      `json:"active"`
+	Internal  bool      `json:"internal"`
+	Range     [2]uint32 `json:"range"`
+}
+
+// jsonSeedIdentity is the compact JSON representation of the identity of a
+// seed.
+type jsonSeedIdentity struct {
+	// SchemaVersion is the version of the JSON output, see
+	// JSONSchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+
+	// MasterFingerprint is the hex-encoded master key fingerprint.
+	MasterFingerprint string `json:"masterFingerprint"`
+
+	// NodePubKey is the hex-encoded compressed node identity key.
+	NodePubKey string `json:"nodePubKey"`
+}
+
+// jsonOwnershipProof is the JSON representation of an ownership proof, in the
+// shape exchanges commonly ask for.
+type jsonOwnershipProof struct {
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+	Path      string `json:"path"`
+	Format    string `json:"format"`
+}
+
+// jsonXpub is the JSON representation of the extended keys of an account.
+type jsonXpub struct {
+	Scope string `json:"scope"`
+	Path  string `json:"path"`
+	Xpub  string `json:"xpub,omitempty"`
+	Xprv  string `json:"xprv,omitempty"`
+}
+
+// jsonAddr is the JSON representation of a single derived address.
+type jsonAddr struct {
+	Type         string       `json:"type"`
+	Path         string       `json:"path"`
+	Address      string       `json:"address"`
+	ScriptPubKey string       `json:"scriptPubKey,omitempty"`
+	Descriptor   string       `json:"descriptor,omitempty"`
+	WIF          string       `json:"wif,omitempty"`
+	Balance      *jsonBalance `json:"balance,omitempty"`
+}
+
+// jsonBalance is the JSON representation of the balance of an address.
+type jsonBalance struct {
+	ConfirmedSat int64  `json:"confirmedSat"`
+	TxCount      uint64 `json:"txCount"`
+}
+
+// PrintSeedIdentity writes the passed seed identity to w, either as two lines
+// of text or, in the JSON format, as a compact JSON object on a single line.
+func PrintSeedIdentity(w io.Writer, id *SeedIdentity, format string) error {
+	fingerprint := hex.EncodeToString(id.Fingerprint[:])
+	nodePub := hex.EncodeToString(id.NodePub.SerializeCompressed())
+
+	switch format {
+	case FormatText:
+		_, err := fmt.Fprintf(w, "Master fingerprint: %v\nNode pub "+
+			"key: %v\n", fingerprint, nodePub)
+		return err
+
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(jsonSeedIdentity{
+			SchemaVersion:     JSONSchemaVersion,
+			MasterFingerprint: fingerprint,
+			NodePubKey:        nodePub,
+		})
+
+	default:
+		return fmt.Errorf("the seed identity can only be printed in "+
+			"the text or json format, not %v", format)
+	}
+}
+
+// PrintOwnershipProof writes the passed ownership proof to w as a JSON object,
+// which can be submitted as is.
+func PrintOwnershipProof(w io.Writer, proof *OwnershipProof) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(jsonOwnershipProof{
+		Address:   proof.Address.EncodeAddress(),
+		Message:   proof.Message,
+		Signature: proof.Signature,
+		Path:      proof.Path.String(),
+		Format:    proof.Format,
+	})
+}
+
+// PrintMnemonic writes the passed mnemonic to w in numbered columns, in the
+// same layout lncli uses.
+func PrintMnemonic(w io.Writer, m aezeed.Mnemonic) {
+	const numCols = 4
+
+	var maxLen int
+	for _, word := range m {
+		if len(word) > maxLen {
+			maxLen = len(word)
+		}
+	}
+
+	fmt.Fprintln(w, "---------------BEGIN LND CIPHER SEED---------------")
+	for i, word := range m {
+		if (i+1)%numCols == 0 {
+			fmt.Fprintf(w, "%2d. %v\n", i+1, word)
+			continue
+		}
+		fmt.Fprintf(w, "%2d. %-*s  ", i+1, maxLen, word)
+	}
+	fmt.Fprintln(w, "---------------END LND CIPHER SEED-----------------")
+}
+
+// PrintMnemonicLines writes the passed mnemonic to w with one numbered word per
+// line, which is the canonical way to write down an aezeed.
+func PrintMnemonicLines(w io.Writer, m aezeed.Mnemonic) {
+	for i, word := range m {
+		fmt.Fprintf(w, "%2d. %v\n", i+1, word)
+	}
+}
+
+// printAddr writes the passed address to w under the given label, followed by
+// the path it was derived at. If scripts is true, then the hex-encoded output
+// script paying to the address is printed on the same line.
+func printAddr(w io.Writer, label string, a derivedAddr, scripts bool) error {
+	line := fmt.Sprintf("%v %v [%v]", label, a.addr, a.path)
+
+	if scripts {
+		pkScript, err := PayToAddrScript(a.addr)
+		if err != nil {
+			return fmt.Errorf("unable to create script for %v: %w",
+				a.addr, err)
+		}
+		line += fmt.Sprintf(" (scriptPubKey: %x)", pkScript)
+	}
+
+	if a.balance != nil {
+		line += fmt.Sprintf(" balance: %v in %v tx(s)",
+			a.balance.Confirmed, a.balance.TxCount)
+	}
+
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// printQR writes the passed data to w as a QR code, warning if it's large
+// enough to be hard to scan.
+func printQR(w io.Writer, what, data string) error {
+	q, err := EncodeQR([]byte(data), QRLevelM)
+	if err != nil {
+		return fmt.Errorf("unable to encode QR code of %v: %w", what,
+			err)
+	}
+
+	if q.Version > qrComfortableVersion {
+		fmt.Fprintf(w, "Warning: the QR code of the %v is version %v, "+
+			"which may be hard to scan\n", what, q.Version)
+	}
+
+	return q.WriteText(w)
+}
+
+// printSeedHeader writes everything that's known about the seed itself to w,
+// followed by the node key.
+func printSeedHeader(w io.Writer, res *recoveryResult, cfg *Config) {
+	if res.mnemonic != nil {
+		if cfg.MnemonicLines {
+			PrintMnemonicLines(w, *res.mnemonic)
+		} else {
+			PrintMnemonic(w, *res.mnemonic)
+		}
+	}
+	fmt.Fprintf(w, "Mnemonic Version: %v\n", res.mnemonicVersion)
+	fmt.Fprintf(w, "Wallet Birthday: %v, Internal Version: %v\n",
+		FormatBirthday(res.birthday, cfg.BirthdayFormat),
+		res.internalVersion)
+	fmt.Fprintf(w, "Estimated birthday block height: %v (approximate, "+
+		"assuming 10 minute blocks)\n", res.birthdayHeight)
+	if res.entropy != nil {
+		fmt.Fprintf(w, "Seed entropy: %x\n", res.entropy)
+	}
+	if res.bip39 != nil {
+		fmt.Fprintf(w, "BIP39 mnemonic (derives different addresses "+
+			"than the aezeed!): %v\n", strings.Join(res.bip39, " "))
+	}
+
+	fmt.Fprintf(w, "Master fingerprint: %x\n", res.fingerprint[:])
+
+	nodePubHex := hex.EncodeToString(res.nodePub.SerializeCompressed())
+	fmt.Fprintf(w, "Node pub key:  %v [%v]\n", nodePubHex, res.nodePath)
+	if cfg.Uncompressed {
+		fmt.Fprintf(w, "Node pub key (uncompressed): %x\n",
+			res.nodePub.SerializeUncompressed())
+	}
+}
+
+// printText writes the result to w in the default human readable format.
+// Unless only the external branch was derived, the addresses of each branch
+// are printed under their own header. For a bulk import, the addresses are
+// instead printed as tab separated lines.
+func printText(w io.Writer, res *recoveryResult, cfg *Config) error {
+	bulkImport := cfg.BulkImport
+	sections := cfg.Branch != "external" && !bulkImport
+
+	if err := printTextHeader(w, res, cfg); err != nil {
+		return err
+	}
+
+	for i, a := range res.addrs {
+		if bulkImport {
+			fields := []string{
+				a.addrType.name, a.path.String(),
+				a.addr.EncodeAddress(),
+			}
+			if a.wif != "" {
+				fields = append(fields, a.wif)
+			}
+			if a.balance != nil {
+				fields = append(
+					fields, a.balance.Confirmed.String(),
+				)
+			}
+			fmt.Fprintln(w, strings.Join(fields, "\t"))
+			continue
+		}
+
+		// With more than one account, the addresses of each account
+		// are printed under their own header.
+		newAccount := i == 0 ||
+			res.addrs[i-1].path.Account != a.path.Account
+		if len(cfg.Accounts) > 1 && newAccount {
+			fmt.Fprintf(w, "\nAccount %d:\n", a.path.Account)
+		}
+
+		branch := a.path.Branch
+		newBranch := newAccount || res.addrs[i-1].path.Branch != branch
+		if sections && newBranch {
+			fmt.Fprintf(w, "\n%v addresses:\n", branchNames[branch])
+		}
+
+		label := fmt.Sprintf("%v address #%v:", a.addrType.name,
+			a.path.Index)
+		err := printAddr(w, label, a, cfg.Scripts)
+		if err != nil {
+			return err
+		}
+		if a.wif != "" {
+			fmt.Fprintf(w, "%v WIF: %v\n", a.addrType.name, a.wif)
+		}
+		if a.internalKey != nil {
+			desc, err := BuildTaprootKeyDescriptor(
+				res.fingerprint, a.path, a.internalKey,
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%v descriptor: %v\n", a.addrType.name,
+				desc)
+		}
+		if cfg.QR {
+			what := fmt.Sprintf("%v address", a.addrType.name)
+			err := printQR(w, what, a.addr.EncodeAddress())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// printTextHeader writes everything but the derived addresses to w in the
+// human readable format, which is shared by the text and table formats.
+func printTextHeader(w io.Writer, res *recoveryResult, cfg *Config) error {
+	// Without the seed, the account xpub that the addresses are derived
+	// from takes the place of everything we'd know about the seed.
+	if x := res.watchAccount; x != nil {
+		fmt.Fprintf(w, "Watch-only %v account xpub: %v [%v]\n",
+			x.addrType.scope, x.xpub, x.path.AccountPath())
+	} else {
+		printSeedHeader(w, res, cfg)
+	}
+
+	for _, k := range res.familyKeys {
+		fmt.Fprintf(w, "Key family %d (%v): %x [%v]\n", k.family,
+			KeyFamilyName(k.family), k.pub.SerializeCompressed(),
+			k.path)
+		if cfg.Uncompressed {
+			fmt.Fprintf(w, "Key family %d (%v) uncompressed: %x\n",
+				k.family, KeyFamilyName(k.family),
+				k.pub.SerializeUncompressed())
+		}
+	}
+
+	if k := res.locatorKey; k != nil {
+		fmt.Fprintf(w, "Key family %d index %d: %x [%v]\n",
+			k.loc.Family, k.loc.Index, k.pub.SerializeCompressed(),
+			k.path)
+		if cfg.Uncompressed {
+			fmt.Fprintf(w, "Key family %d index %d uncompressed: "+
+				"%x\n", k.loc.Family, k.loc.Index,
+				k.pub.SerializeUncompressed())
+		}
+		if k.priv != nil {
+			fmt.Fprintf(w, "Key family %d index %d private key: "+
+				"%x\n", k.loc.Family, k.loc.Index,
+				k.priv.Serialize())
+		}
+	}
+
+	for _, k := range res.towerSessionKeys {
+		fmt.Fprintf(w, "Tower session key index %d: %x [%v]\n",
+			k.loc.Index, k.pub.SerializeCompressed(), k.path)
+	}
+	if k := res.towerIDKey; k != nil {
+		fmt.Fprintf(w, "Tower id key: %x [%v]\n",
+			k.pub.SerializeCompressed(), k.path)
+	}
+
+	for _, s := range res.skippedBackups {
+		fmt.Fprintf(w, "Warning: skipped channel backup %v: %v\n",
+			s.path, s.err)
+	}
+	switch {
+	case cfg.SCBDir != "":
+		fmt.Fprintf(w, "Channel backups (%v file(s)) contain %v "+
+			"unique channel(s):\n", res.numBackupFiles,
+			len(res.channelBackups))
+
+	case res.channelBackups != nil:
+		fmt.Fprintf(w, "Channel backup contains %v channel(s):\n",
+			len(res.channelBackups))
+	}
+	for _, c := range res.channelBackups {
+		fmt.Fprintf(w, "  channel point %v, remote node %x\n",
+			c.ChannelPoint, c.RemoteNodePub.SerializeCompressed())
+	}
+
+	for _, x := range res.xpubs {
+		if x.xpub != "" {
+			fmt.Fprintf(w, "%v account xpub: %v [%v]\n",
+				x.addrType.scope, x.xpub, x.path.AccountPath())
+		}
+		if x.xpub != "" && cfg.QR {
+			what := fmt.Sprintf("%v account xpub", x.addrType.scope)
+			if err := printQR(w, what, x.xpub); err != nil {
+				return err
+			}
+		}
+		if x.xprv != "" {
+			fmt.Fprintf(w, "%v account xprv: %v [%v]\n",
+				x.addrType.scope, x.xprv, x.path.AccountPath())
+		}
+	}
+
+	for _, d := range res.descriptors {
+		branch := strings.ToLower(branchNames[d.branch])
+		fmt.Fprintf(w, "%v %v descriptor: %v\n", d.addrType.scope,
+			branch, d.desc)
+
+		if cfg.QR {
+			what := fmt.Sprintf("%v %v descriptor",
+				d.addrType.scope, branch)
+			if err := printQR(w, what, d.desc); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.Scan && len(res.addrs) == 0 {
+		fmt.Fprintf(w, "No used addresses found within a gap of %v "+
+			"addresses\n", cfg.GapLimit)
+	}
+
+	return nil
+}
+
+// printTable writes the result to w in the human readable format, with the
+// derived addresses aligned in a table below everything else.
+func printTable(w io.Writer, res *recoveryResult, cfg *Config) error {
+	if err := printTextHeader(w, res, cfg); err != nil {
+		return err
+	}
+	if len(res.addrs) == 0 {
+		return nil
+	}
+
+	header := []string{"TYPE", "PATH", "INDEX", "ADDRESS"}
+	if cfg.Scripts {
+		header = append(header, "SCRIPTPUBKEY")
+	}
+	if cfg.WIF {
+		header = append(header, "WIF")
+	}
+	if cfg.Esplora != "" {
+		header = append(header, "BALANCE", "TXS")
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, a := range res.addrs {
+		row := []string{
+			a.addrType.name,
+			a.path.String(),
+			strconv.FormatUint(uint64(a.path.Index), 10),
+			a.addr.EncodeAddress(),
+		}
+		if cfg.Scripts {
+			pkScript, err := PayToAddrScript(a.addr)
+			if err != nil {
+				return fmt.Errorf("unable to create script "+
+					"for %v: %w", a.addr, err)
+			}
+			row = append(row, hex.EncodeToString(pkScript))
+		}
+		if cfg.WIF {
+			row = append(row, a.wif)
+		}
+		if a.balance != nil {
+			row = append(
+				row, a.balance.Confirmed.String(),
+				strconv.FormatUint(a.balance.TxCount, 10),
+			)
+		}
+
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// newJSONLocatorKey returns the JSON representation of the passed key, which
+// includes its private key if it was derived.
+func newJSONLocatorKey(k *locatorKey, cfg *Config) *jsonLocatorKey {
+	key := &jsonLocatorKey{
+		Family: uint32(k.loc.Family),
+		Index:  k.loc.Index,
+		Path:   k.path.String(),
+		PubKey: hex.EncodeToString(k.pub.SerializeCompressed()),
+	}
+	if cfg.Uncompressed {
+		key.PubKeyUncompressed = hex.EncodeToString(
+			k.pub.SerializeUncompressed(),
+		)
+	}
+	if k.priv != nil {
+		key.PrivKey = hex.EncodeToString(k.priv.Serialize())
+	}
+
+	return key
+}
+
+// printJSON writes the result to w as a single JSON object.
+func printJSON(w io.Writer, res *recoveryResult, cfg *Config) error {
+	addrs, err := jsonAddrs(res, cfg)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if x := res.watchAccount; x != nil {
+		return enc.Encode(jsonWatchResult{
+			SchemaVersion: JSONSchemaVersion,
+			AccountXpub: jsonXpub{
+				Scope: x.addrType.scope,
+				Path:  x.path.AccountPath(),
+				Xpub:  x.xpub,
+			},
+			Addresses: addrs,
+		})
+	}
+
+	out := jsonResult{
+		SchemaVersion:   JSONSchemaVersion,
+		MnemonicVersion: res.mnemonicVersion,
+		Birthday:        res.birthday.Format(time.RFC3339),
+		BirthdayHeight:  res.birthdayHeight,
+		InternalVersion: res.internalVersion,
+		BIP39Mnemonic:   res.bip39,
+		Fingerprint:     hex.EncodeToString(res.fingerprint[:]),
+		NodePubKey: hex.EncodeToString(
+			res.nodePub.SerializeCompressed(),
+		),
+		Addresses: addrs,
+	}
+	if cfg.Uncompressed {
+		out.NodePubKeyUncompressed = hex.EncodeToString(
+			res.nodePub.SerializeUncompressed(),
+		)
+	}
+	if res.mnemonic != nil {
+		out.Mnemonic = res.mnemonic[:]
+	}
+	if res.entropy != nil {
+		out.Entropy = hex.EncodeToString(res.entropy)
+	}
+	for _, k := range res.familyKeys {
+		familyKey := jsonFamilyKey{
+			Family: uint32(k.family),
+			Name:   KeyFamilyName(k.family),
+			Path:   k.path.String(),
+			PubKey: hex.EncodeToString(k.pub.SerializeCompressed()),
+		}
+		if cfg.Uncompressed {
+			familyKey.PubKeyUncompressed = hex.EncodeToString(
+				k.pub.SerializeUncompressed(),
+			)
+		}
+		out.KeyFamilies = append(out.KeyFamilies, familyKey)
+	}
+	if k := res.locatorKey; k != nil {
+		out.KeyLocator = newJSONLocatorKey(k, cfg)
+	}
+	for _, k := range res.towerSessionKeys {
+		out.TowerSessionKeys = append(
+			out.TowerSessionKeys, newJSONLocatorKey(k, cfg),
+		)
+	}
+	if k := res.towerIDKey; k != nil {
+		out.TowerIDKey = newJSONLocatorKey(k, cfg)
+	}
+	for _, c := range res.channelBackups {
+		out.ChannelBackups = append(out.ChannelBackups, jsonChannel{
+			ChannelPoint:   c.ChannelPoint.String(),
+			ShortChannelID: c.ShortChannelID,
+			RemoteNodePub: hex.EncodeToString(
+				c.RemoteNodePub.SerializeCompressed(),
+			),
+			IsInitiator: c.IsInitiator,
+			Version:     c.Version,
+		})
+	}
+	for _, s := range res.skippedBackups {
+		out.SkippedBackups = append(out.SkippedBackups, jsonSkipped{
+			Path:  s.path,
+			Error: s.err.Error(),
+		})
+	}
+	for _, x := range res.xpubs {
+		out.AccountXpubs = append(out.AccountXpubs, jsonXpub{
+			Scope: x.addrType.scope,
+			Path:  x.path.AccountPath(),
+			Xpub:  x.xpub,
+			Xprv:  x.xprv,
+		})
+	}
+	for _, d := range res.descriptors {
+		out.Descriptors = append(out.Descriptors, jsonDesc{
+			Scope:      d.addrType.scope,
+			Internal:   d.branch == InternalBranch,
+			Descriptor: d.desc,
+		})
+	}
+
+	return enc.Encode(out)
+}
+
+// jsonAddrs returns the JSON representation of each of the derived addresses
+// of the result.
+func jsonAddrs(res *recoveryResult, cfg *Config) ([]jsonAddr, error) {
+	addrs := make([]jsonAddr, 0, len(res.addrs))
+	for _, a := range res.addrs {
+		addr := jsonAddr{
+			Type:    a.addrType.name,
+			Path:    a.path.String(),
+			Address: a.addr.EncodeAddress(),
+			WIF:     a.wif,
+		}
+
+		if cfg.Scripts {
+			pkScript, err := PayToAddrScript(a.addr)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create "+
+					"script for %v: %w", a.addr, err)
+			}
+			addr.ScriptPubKey = hex.EncodeToString(pkScript)
+		}
+
+		if a.internalKey != nil {
+			desc, err := BuildTaprootKeyDescriptor(
+				res.fingerprint, a.path, a.internalKey,
+			)
+			if err != nil {
+				return nil, err
+			}
+			addr.Descriptor = desc
+		}
+
+		if a.balance != nil {
+			addr.Balance = &jsonBalance{
+				ConfirmedSat: int64(a.balance.Confirmed),
+				TxCount:      a.balance.TxCount,
+			}
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	return addrs, nil
+}
+
+// printElectrum writes a watch-only Electrum wallet file of the BIP0084
+// account of the result to w, which Electrum can open directly. As the wallet
+// is watch-only, neither the mnemonic nor any private key is ever included.
+func printElectrum(w io.Writer, res *recoveryResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(jsonElectrumWallet{
+		Keystore: jsonElectrumKeystore{
+			Type:            "bip32",
+			Xpub:            res.electrumAccount.xpub,
+			Derivation:      res.electrumAccount.path.AccountPath(),
+			RootFingerprint: hex.EncodeToString(res.fingerprint[:]),
+		},
+		WalletType:  "standard",
+		SeedVersion: electrumSeedVersion,
+	})
+}
+
+// printSparrow writes a watch-only wallet file of a single account of the
+// result to w, which Sparrow imports as a Specter Desktop wallet. The account
+// is the one of the only selected address type, or the BIP0084 one by default.
+// On mainnet, the estimated height of the birthday of the seed is included, so
+// Sparrow doesn't scan the chain from the genesis block.
+func printSparrow(w io.Writer, res *recoveryResult, cfg *Config) error {
+	addrTypeName := "p2wkh"
+	if len(cfg.AddrTypes) == 1 {
+		addrTypeName = cfg.AddrTypes[0]
+	}
+
+	wallet := jsonSparrowWallet{
+		Label: fmt.Sprintf("aezeed %x", res.fingerprint[:]),
+	}
+	for _, d := range res.descriptors {
+		if d.addrType.name == addrTypeName &&
+			d.branch == ExternalBranch {
+
+			wallet.Descriptor = d.desc
+		}
+	}
+	if wallet.Descriptor == "" {
+		return fmt.Errorf("no %v account was derived", addrTypeName)
+	}
+	if cfg.Network == "mainnet" {
+		wallet.BlockHeight = EstimateBlockHeight(res.birthday)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(wallet)
+}
+
+// coldcardSections are the headings of the accounts in the Coldcard summary,
+// by their BIP0043 purpose. Any account not listed isn't part of it.
+var coldcardSections = map[uint32]string{
+	waddrmgr.KeyScopeBIP0084.Purpose:     "BIP84 (Native Segwit P2WPKH)",
+	waddrmgr.KeyScopeBIP0049Plus.Purpose: "BIP49 (P2WPKH-in-P2SH)",
+	waddrmgr.KeyScopeBIP0044.Purpose:     "BIP44 (P2PKH)",
+}
+
+// printColdcard writes the BIP0084, BIP0049 and BIP0044 account xpubs of the
+// result to w in the layout of the wallet summary file of a Coldcard signer,
+// along with the master fingerprint and the first receive addresses of each
+// account. The xpubs are SLIP-0132 encoded, just like Coldcard does. As the
+// summary is meant for a watch-only wallet, neither the mnemonic nor any
+// private key is ever included.
+func printColdcard(w io.Writer, res *recoveryResult, cfg *Config) error {
+	fmt.Fprintln(w, "# Coldcard Wallet Summary File")
+	fmt.Fprintf(w, "## For wallet with master key fingerprint: %X\n\n",
+		res.fingerprint[:])
+	fmt.Fprintf(w, "Wallet operates on blockchain: %v (%v)\n",
+		strings.ToUpper(cfg.Coin), cfg.Network)
+
+	for _, x := range res.xpubs {
+		section, ok := coldcardSections[x.path.Purpose]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n## %v\n\n", section)
+		fmt.Fprintf(w, "%v => %v\n\n", x.path.AccountPath(), x.xpub)
+		fmt.Fprintf(w, "First %v receive addresses (account=%v, "+
+			"change=0):\n\n", cfg.Count, x.path.Account)
+		for _, a := range res.addrs {
+			if a.path.Purpose != x.path.Purpose ||
+				a.path.Account != x.path.Account ||
+				a.path.Branch != ExternalBranch {
+
+				continue
+			}
+			fmt.Fprintf(w, "%v => %v\n", a.path, a.addr)
+		}
+	}
+
+	return nil
+}
+
+// printBitcoind writes the descriptors of the result to w as a JSON array that
+// can be passed to bitcoind's importdescriptors RPC. The rescan timestamp is
+// set to the birthday of the seed, and each descriptor is imported with the
+// range [rangeStart, rangeEnd]. As there's no place for it in the payload, the
+// mnemonic is never included.
+func printBitcoind(w io.Writer, res *recoveryResult, rangeStart,
+	rangeEnd uint32) error {
+
+	out := make([]jsonImportDesc, 0, len(res.descriptors))
+	for _, d := range res.descriptors {
+		out = append(out, jsonImportDesc{
+			Desc:      d.desc,
+			Timestamp: res.birthday.Unix(),
+			Active:    true,
+			Internal:  d.branch == InternalBranch,
+			Range:     [2]uint32{rangeStart, rangeEnd},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+}
+
+// printCSV writes one CSV row per derived address to w, preceded by a header
+// row. If CSV comments are enabled, then the birthday and node key are printed
+// as comment lines prefixed with '#' before the header. As there's no place
+// for it in the CSV, the mnemonic is never included.
+func printCSV(w io.Writer, res *recoveryResult, cfg *Config) error {
+	if x := res.watchAccount; x != nil && cfg.CSVComments {
+		fmt.Fprintf(w, "# Watch-only %v account xpub: %v [%v]\n",
+			x.addrType.scope, x.xpub, x.path.AccountPath())
+	} else if cfg.CSVComments {
+		fmt.Fprintf(w, "# Wallet Birthday: %v, Internal Version: %v\n",
+			FormatBirthday(res.birthday, cfg.BirthdayFormat),
+			res.internalVersion)
+		fmt.Fprintf(w, "# Estimated birthday block height: %v "+
+			"(approximate)\n", res.birthdayHeight)
+		fmt.Fprintf(w, "# Master fingerprint: %x\n", res.fingerprint[:])
+		fmt.Fprintf(w, "# Node pub key: %x [%v]\n",
+			res.nodePub.SerializeCompressed(), res.nodePath)
+		if cfg.Uncompressed {
+			fmt.Fprintf(w, "# Node pub key (uncompressed): %x\n",
+				res.nodePub.SerializeUncompressed())
+		}
+	}
+
+	// The WIF column is only added if requested, so the private keys
+	// can't end up in a CSV by accident.
+	header := []string{
+		"type", "path", "index", "address", "scriptPubKey",
+	}
+	if cfg.WIF {
+		header = append(header, "wif")
+	}
+	if cfg.Esplora != "" {
+		header = append(header, "balanceSat", "txCount")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, a := range res.addrs {
+		pkScript, err := PayToAddrScript(a.addr)
+		if err != nil {
+			return fmt.Errorf("unable to create script for %v: %w",
+				a.addr, err)
+		}
+
+		row := []string{
+			a.addrType.name,
+			a.path.String(),
+			strconv.FormatUint(uint64(a.path.Index), 10),
+			a.addr.EncodeAddress(),
+			hex.EncodeToString(pkScript),
+		}
+		if cfg.WIF {
+			row = append(row, a.wif)
+		}
+		if a.balance != nil {
+			confirmed := int64(a.balance.Confirmed)
+			row = append(
+				row, strconv.FormatInt(confirmed, 10),
+				strconv.FormatUint(a.balance.TxCount, 10),
+			)
+		}
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,87 @@
+package aezeedcheck
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// CoinBitcoin selects Bitcoin, which is the default coin.
+	CoinBitcoin = "btc"
+
+	// CoinLitecoin selects Litecoin, which lnd used to support as well.
+	CoinLitecoin = "ltc"
+)
+
+// SigNetParams are the chain parameters of the default signet. The version
+// of btcd we depend on predates signet, so we base them on the testnet3
+// parameters, which share the same address encodings and HD coin type.
+var SigNetParams = func() chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "signet"
+	params.Net = wire.BitcoinNet(0x40cf030a)
+	params.DefaultPort = "38333"
+
+	return params
+}()
+
+// SimNetParams are the chain parameters of simnet. Although simnet defines its
+// own HD coin type of 115, lnd falls back to the testnet coin type on simnet,
+// so we override it to derive the same keys.
+var SimNetParams = func() chaincfg.Params {
+	params := chaincfg.SimNetParams
+	params.HDCoinType = keychain.CoinTypeTestnet
+
+	return params
+}()
+
+// LitecoinMainNetParams are the chain parameters of the Litecoin mainnet. As
+// btcd doesn't ship them, we base them on the Bitcoin mainnet parameters, and
+// only override the network magic, address encodings and HD coin type,
+// which is all that's needed to derive addresses. Just like Litecoin Core,
+// the extended keys use the same xprv/xpub version bytes as Bitcoin.
+var LitecoinMainNetParams = func() chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.Name = "litecoin"
+	params.Net = wire.BitcoinNet(0xdbb6c0fb)
+	params.DefaultPort = "9333"
+	params.DNSSeeds = nil
+	params.Checkpoints = nil
+	params.PubKeyHashAddrID = 0x30
+	params.ScriptHashAddrID = 0x32
+	params.PrivateKeyID = 0xb0
+	params.Bech32HRPSegwit = "ltc"
+	params.HDCoinType = keychain.CoinTypeLitecoin
+
+	return params
+}()
+
+// NetParams couples a set of chain parameters with the BIP0044 coin type
+// used when deriving the wallet's address scopes on that network.
+type NetParams struct {
+	*chaincfg.Params
+
+	// CoinType is the coin type of all derivation paths, which is the
+	// HDCoinType of the chain parameters unless overridden.
+	CoinType uint32
+}
+
+// ChainParams maps the name of each supported Bitcoin network to its set of
+// chain parameters.
+var ChainParams = map[string]*chaincfg.Params{
+	"mainnet":  &chaincfg.MainNetParams,
+	"testnet3": &chaincfg.TestNet3Params,
+	"regtest":  &chaincfg.RegressionNetParams,
+	"signet":   &SigNetParams,
+	"simnet":   &SimNetParams,
+}
+
+// CoinParams maps each supported coin to the networks it can be used on,
+// along with their chain parameters.
+var CoinParams = map[string]map[string]*chaincfg.Params{
+	CoinBitcoin: ChainParams,
+	CoinLitecoin: {
+		"mainnet": &LitecoinMainNetParams,
+	},
+}
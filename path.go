@@ -0,0 +1,137 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// ParseDerivationPath parses a BIP0032 derivation path such as
+// m/48'/0'/0'/2'/0/0, with hardened elements marked by either ' or h, into the
+// child numbers of its elements, including the hardened offset.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	elements := strings.Split(strings.TrimSpace(path), "/")
+	if elements[0] != "m" {
+		return nil, fmt.Errorf("invalid path %q: must start with m",
+			path)
+	}
+
+	childNums := make([]uint32, 0, len(elements)-1)
+	for i, element := range elements[1:] {
+		// We'll refer to the elements by their depth, which matches
+		// their position after the leading m.
+		depth := i + 1
+
+		var offset uint32
+		trimmed := strings.TrimRight(element, "'h")
+		switch len(element) - len(trimmed) {
+		case 0:
+
+		case 1:
+			offset = hdkeychain.HardenedKeyStart
+
+		default:
+			return nil, fmt.Errorf("invalid path element %v (%q): "+
+				"marked as hardened more than once", depth,
+				element)
+		}
+
+		if trimmed == "" {
+			return nil, fmt.Errorf("invalid path element %v (%q): "+
+				"missing index", depth, element)
+		}
+
+		index, err := strconv.ParseUint(trimmed, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path element %v (%q): "+
+				"index must be a number", depth, element)
+		}
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("invalid path element %v (%q): "+
+				"index must be below %v", depth, element,
+				hdkeychain.HardenedKeyStart)
+		}
+
+		childNums = append(childNums, uint32(index)+offset)
+	}
+
+	return childNums, nil
+}
+
+// FormatDerivationPath renders the passed child numbers as a derivation path,
+// marking hardened elements with '.
+func FormatDerivationPath(childNums []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, childNum := range childNums {
+		if childNum >= hdkeychain.HardenedKeyStart {
+			childNum -= hdkeychain.HardenedKeyStart
+			fmt.Fprintf(&b, "/%d'", childNum)
+			continue
+		}
+		fmt.Fprintf(&b, "/%d", childNum)
+	}
+
+	return b.String()
+}
+
+// DerivePathAddr derives the key at the passed derivation path from the root
+// key of the cipher seed, and returns it along with its address of the given
+// type. Unlike the other derivations, the path isn't tied to any key scope, so
+// the address type can be chosen freely.
+func DerivePathAddr(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	childNums []uint32, addrTypeName string) (*btcec.PublicKey,
+	btcutil.Address, error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		t     addrType
+		found bool
+	)
+	for _, candidate := range addrTypes(cfg) {
+		if candidate.name == addrTypeName {
+			t, found = candidate, true
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("unknown address type %q",
+			addrTypeName)
+	}
+
+	key, err := hdkeychain.NewMaster(cipherSeed.Entropy[:], params.Params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to make HD priv root: %w",
+			err)
+	}
+	for _, childNum := range childNums {
+		child, err := key.Child(childNum)
+		key.Zero()
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to derive %v: %w",
+				FormatDerivationPath(childNums), err)
+		}
+		key = child
+	}
+	defer key.Zero()
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := t.keyToAddr(pubKey, params.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pubKey, addr, nil
+}
@@ -0,0 +1,64 @@
+package aezeedcheck
+
+import (
+	"errors"
+)
+
+// PlannedPath is the derivation path of an address that Run would derive.
+type PlannedPath struct {
+	// AddrType is the name of the type of the address, e.g. p2wkh.
+	AddrType string
+
+	// Path is the derivation path of the key of the address.
+	Path KeyPath
+}
+
+// PlanPaths returns the derivation paths of the addresses that Run would
+// derive from the seed with the passed config, in the order they'd be
+// printed. No keys are derived, so the seed itself isn't needed.
+func PlanPaths(cfg *Config) ([]PlannedPath, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.WatchXpub != "" {
+		return nil, errors.New("the paths of a watch-only account " +
+			"can't be planned")
+	}
+	if cfg.Scan {
+		return nil, errors.New("the paths found by a scan depend on " +
+			"the usage of the addresses")
+	}
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	numAddrs := cfg.Count
+	if cfg.BulkImport {
+		numAddrs += cfg.GapLimit
+	}
+
+	var paths []PlannedPath
+	for _, account := range cfg.accounts() {
+		for _, b := range branchSelections[cfg.Branch] {
+			for _, t := range selectedAddrTypes(cfg) {
+				path := KeyPath{
+					Purpose:  t.purpose,
+					CoinType: params.CoinType,
+					Account:  account,
+					Branch:   b,
+				}
+				for i := uint32(0); i < numAddrs; i++ {
+					path.Index = cfg.FirstIndex + i
+					paths = append(paths, PlannedPath{
+						AddrType: t.name,
+						Path:     path,
+					})
+				}
+			}
+		}
+	}
+
+	return paths, nil
+}
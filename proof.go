@@ -0,0 +1,259 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+const (
+	// ProofFormatBIP137 is the format of an ownership proof signed in the
+	// Bitcoin Signed Message format, with the BIP0137 header byte of
+	// segwit addresses.
+	ProofFormatBIP137 = "bip137"
+
+	// ProofFormatBIP322 is the format of an ownership proof signed as a
+	// BIP0322 simple signature, which is the only way to sign a message
+	// with a p2tr address.
+	ProofFormatBIP322 = "bip322-simple"
+
+	// bip322MessageTag is the tag of the BIP0340 tagged hash of the
+	// message that a BIP0322 signature commits to.
+	bip322MessageTag = "BIP0322-signed-message"
+
+	// sigHashDefault is the BIP0341 sighash type that commits to all
+	// inputs and outputs, signaled by omitting the sighash byte.
+	sigHashDefault = 0x00
+)
+
+// OwnershipProof is a signature over a challenge, e.g. one provided by an
+// exchange, that proves control over the key of an address.
+type OwnershipProof struct {
+	// Address is the address whose key made the signature.
+	Address btcutil.Address
+
+	// Path is the derivation path of the key.
+	Path KeyPath
+
+	// Message is the challenge that was signed.
+	Message string
+
+	// Signature is the base64-encoded signature over the message.
+	Signature string
+
+	// Format is the format of the signature, which is one of the
+	// ProofFormat constants.
+	Format string
+}
+
+// bip322ToSpend returns the virtual to_spend transaction of BIP0322, whose
+// only output pays to the passed script and commits to the message.
+func bip322ToSpend(pkScript, msg []byte) (*wire.MsgTx, error) {
+	msgHash := taggedHash(bip322MessageTag, msg)
+	sigScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(msgHash[:]).
+		Script()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(0)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  sigScript,
+		Sequence:         0,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	return tx, nil
+}
+
+// bip322TaprootSigHash returns the BIP0341 key path sighash of the virtual
+// to_sign transaction of BIP0322, which spends the only output of to_spend
+// with the passed output script to a single OP_RETURN output.
+func bip322TaprootSigHash(pkScript, msg []byte) ([]byte, error) {
+	toSpend, err := bip322ToSpend(pkScript, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both transactions have a single input and output, a version and
+	// lock time of zero, and every input has a sequence of zero.
+	var prevOuts bytes.Buffer
+	prevOut := wire.OutPoint{Hash: toSpend.TxHash()}
+	prevOuts.Write(prevOut.Hash[:])
+	err = binary.Write(&prevOuts, binary.LittleEndian, prevOut.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	var amounts [8]byte
+
+	var scriptPubKeys bytes.Buffer
+	if err := wire.WriteVarBytes(&scriptPubKeys, 0, pkScript); err != nil {
+		return nil, err
+	}
+
+	var sequences [4]byte
+
+	var outputs bytes.Buffer
+	opReturn := wire.NewTxOut(0, []byte{txscript.OP_RETURN})
+	if err := wire.WriteTxOut(&outputs, 0, 0, opReturn); err != nil {
+		return nil, err
+	}
+
+	var sigMsg bytes.Buffer
+	sigMsg.WriteByte(0) // The sighash epoch.
+	sigMsg.WriteByte(sigHashDefault)
+	sigMsg.Write(make([]byte, 4)) // The version of to_sign.
+	sigMsg.Write(make([]byte, 4)) // The lock time of to_sign.
+	for _, field := range [][]byte{
+		prevOuts.Bytes(), amounts[:], scriptPubKeys.Bytes(),
+		sequences[:], outputs.Bytes(),
+	} {
+		hash := sha256.Sum256(field)
+		sigMsg.Write(hash[:])
+	}
+	sigMsg.WriteByte(0)           // A key path spend without an annex.
+	sigMsg.Write(make([]byte, 4)) // The index of the input.
+
+	sigHash := taggedHash("TapSighash", sigMsg.Bytes())
+
+	return sigHash[:], nil
+}
+
+// SignBIP322Taproot signs the passed message with the internal key of a
+// BIP0086 p2tr address as a BIP0322 simple signature, and returns it
+// base64-encoded.
+func SignBIP322Taproot(internalKey *btcec.PrivateKey,
+	msg []byte) (string, error) {
+
+	outputKey := taprootOutputKey(internalKey.PubKey())
+	pkScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).
+		AddData(outputKey).
+		Script()
+	if err != nil {
+		return "", err
+	}
+
+	sigHash, err := bip322TaprootSigHash(pkScript, msg)
+	if err != nil {
+		return "", err
+	}
+
+	tweakedKey := taprootTweakPrivKey(internalKey)
+	sig, err := schnorrSign(tweakedKey, sigHash)
+	if err != nil {
+		return "", err
+	}
+
+	// A simple signature is the serialized witness of the only input of
+	// to_sign, which is just the signature for a key path spend.
+	var witness bytes.Buffer
+	if err := wire.WriteVarInt(&witness, 0, 1); err != nil {
+		return "", err
+	}
+	if err := wire.WriteVarBytes(&witness, 0, sig[:]); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(witness.Bytes()), nil
+}
+
+// ProveAddrOwnership derives the key of the address of the given type, e.g.
+// p2wkh, at the passed index of the external branch of its account, and signs
+// the challenge with it. The Bitcoin Signed Message format is used for all
+// address types but p2tr, which is signed as a BIP0322 simple signature.
+func ProveAddrOwnership(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	addrTypeName string, index uint32,
+	challenge string) (*OwnershipProof, error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		t     addrType
+		found bool
+	)
+	for _, candidate := range addrTypes(cfg) {
+		if candidate.name == addrTypeName {
+			t, found = candidate, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown address type %q",
+			addrTypeName)
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	defer rootKey.Zero()
+
+	accountKey, err := DeriveAccountKey(
+		rootKey, t.purpose, params.CoinType, 0,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer accountKey.Zero()
+
+	child, err := deriveLocatorChild(accountKey, index)
+	if err != nil {
+		return nil, err
+	}
+	defer child.Zero()
+
+	privKey, err := child.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroPrivKey(privKey)
+
+	proof := &OwnershipProof{
+		Path: KeyPath{
+			Purpose:  t.purpose,
+			CoinType: params.CoinType,
+			Branch:   ExternalBranch,
+			Index:    index,
+		},
+		Message: challenge,
+	}
+	proof.Address, err = t.keyToAddr(privKey.PubKey(), params.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.purpose == BIP0086Purpose {
+		proof.Format = ProofFormatBIP322
+		proof.Signature, err = SignBIP322Taproot(
+			privKey, []byte(challenge),
+		)
+	} else {
+		proof.Format = ProofFormatBIP137
+		proof.Signature, err = SignBitcoinMessage(
+			privKey, proof.Address, t.compressed, []byte(challenge),
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign challenge: %w", err)
+	}
+
+	return proof, nil
+}
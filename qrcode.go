@@ -0,0 +1,700 @@
+package aezeedcheck
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// QRLevel is the error correction level of a QR code, which determines how
+// much of the code can be damaged while still being readable.
+type QRLevel int
+
+const (
+	// QRLevelL recovers roughly 7% of the codewords.
+	QRLevelL QRLevel = iota
+
+	// QRLevelM recovers roughly 15% of the codewords.
+	QRLevelM
+
+	// QRLevelQ recovers roughly 25% of the codewords.
+	QRLevelQ
+
+	// QRLevelH recovers roughly 30% of the codewords.
+	QRLevelH
+)
+
+const (
+	// qrMinVersion and qrMaxVersion bound the versions, and with that the
+	// sizes, of the QR codes we encode.
+	qrMinVersion = 1
+	qrMaxVersion = 40
+
+	// qrQuietZone is the number of light modules that must surround a QR
+	// code for it to be recognized.
+	qrQuietZone = 4
+
+	// qrComfortableVersion is the largest version of a QR code that's
+	// still easily scanned from a screen or a sheet of paper by a phone.
+	qrComfortableVersion = 10
+)
+
+// errQRTooLong is returned when data doesn't fit in the largest QR code.
+var errQRTooLong = errors.New("data too long for a QR code")
+
+// qrFormatBits are the two bits that identify each error correction level in
+// the format information of a QR code.
+var qrFormatBits = [4]uint32{1, 0, 3, 2}
+
+// qrECCPerBlock holds the number of error correction codewords of each block,
+// indexed by error correction level and version.
+var qrECCPerBlock = [4][qrMaxVersion + 1]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24,
+		28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30,
+		30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28,
+		28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+		28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24,
+		28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30,
+		30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30,
+		28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+		30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// qrNumBlocks holds the number of error correction blocks the codewords are
+// split into, indexed by error correction level and version.
+var qrNumBlocks = [4][qrMaxVersion + 1]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9,
+		9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22,
+		24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+		17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40,
+		43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21,
+		20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53,
+		56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25,
+		25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63,
+		66, 70, 74, 77, 81},
+}
+
+// QRCode is an encoded QR code, made up of a square grid of modules.
+type QRCode struct {
+	// Version is the version of the QR code, between 1 and 40.
+	Version int
+
+	// Size is the number of modules along each side of the QR code,
+	// excluding the quiet zone.
+	Size int
+
+	// modules holds the color of each module, indexed by row and column,
+	// with true being dark.
+	modules [][]bool
+
+	// isFunction marks the modules that are part of a function pattern,
+	// rather than data.
+	isFunction [][]bool
+}
+
+// Dark returns true if the module at the given column and row is dark. Any
+// module outside of the code is part of the quiet zone, and thus light.
+func (q *QRCode) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.Size || y >= q.Size {
+		return false
+	}
+
+	return q.modules[y][x]
+}
+
+// WriteText renders the QR code, including its quiet zone, with Unicode half
+// block characters, so each line of text holds two rows of modules.
+// Terminals usually print light text on a dark background, so we draw the
+// light modules, which scanners read just like a dark code on a light
+// background.
+func (q *QRCode) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for y := -qrQuietZone; y < q.Size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < q.Size+qrQuietZone; x++ {
+			top, bottom := !q.Dark(x, y), !q.Dark(x, y+1)
+
+			// An odd number of rows leaves the bottom half of the
+			// last line outside of the quiet zone.
+			if y+1 >= q.Size+qrQuietZone {
+				bottom = false
+			}
+
+			switch {
+			case top && bottom:
+				bw.WriteString("\u2588")
+			case top:
+				bw.WriteString("\u2580")
+			case bottom:
+				bw.WriteString("\u2584")
+			default:
+				bw.WriteString(" ")
+			}
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
+
+// Image renders the QR code, including its quiet zone, as a black on white
+// image, with each module drawn as a square of scale by scale pixels.
+func (q *QRCode) Image(scale int) image.Image {
+	side := (q.Size + 2*qrQuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for py := 0; py < side; py++ {
+		for px := 0; px < side; px++ {
+			x := px/scale - qrQuietZone
+			y := py/scale - qrQuietZone
+
+			c := color.Gray{Y: 0xff}
+			if q.Dark(x, y) {
+				c = color.Gray{}
+			}
+			img.SetGray(px, py, c)
+		}
+	}
+
+	return img
+}
+
+// EncodeQR encodes the passed data in byte mode as a QR code of the smallest
+// version it fits in with the given error correction level.
+func EncodeQR(data []byte, level QRLevel) (*QRCode, error) {
+	version := -1
+	for v := qrMinVersion; v <= qrMaxVersion; v++ {
+		capacity := qrNumDataCodewords(v, level) * 8
+		if 4+qrCharCountBits(v)+len(data)*8 <= capacity {
+			version = v
+			break
+		}
+	}
+	if version == -1 {
+		return nil, errQRTooLong
+	}
+
+	// The data is prefixed by the byte mode indicator and its length,
+	// then terminated and padded to the capacity of the version.
+	var bits qrBitBuffer
+	bits.append(0x4, 4)
+	bits.append(uint32(len(data)), qrCharCountBits(version))
+	for _, b := range data {
+		bits.append(uint32(b), 8)
+	}
+
+	capacity := qrNumDataCodewords(version, level) * 8
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := uint32(0xec); len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+
+	q := newQRCode(version)
+	q.drawFunctionPatterns(level)
+	q.drawCodewords(qrAddECCAndInterleave(codewords, version, level))
+
+	// We'll pick the mask that results in the lowest penalty, which makes
+	// the code easiest to scan. Applying a mask twice undoes it.
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(level, mask)
+		penalty := q.penalty()
+		if bestPenalty == -1 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(bestMask)
+	q.drawFormatBits(level, bestMask)
+
+	return q, nil
+}
+
+// newQRCode returns an empty QR code of the given version.
+func newQRCode(version int) *QRCode {
+	size := version*4 + 17
+	q := &QRCode{
+		Version:    version,
+		Size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.isFunction[i] = make([]bool, size)
+	}
+
+	return q
+}
+
+// qrBitBuffer is a sequence of bits, appended most significant bit first.
+type qrBitBuffer []bool
+
+// append appends the lowest n bits of val to the buffer.
+func (b *qrBitBuffer) append(val uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (val>>uint(i))&1 == 1)
+	}
+}
+
+// qrCharCountBits returns the size of the character count field of byte mode
+// in the given version.
+func qrCharCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+
+	return 16
+}
+
+// qrNumRawDataModules returns the number of modules of the given version
+// that are available for data and error correction codewords.
+func qrNumRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+
+	return result
+}
+
+// qrNumDataCodewords returns the number of data codewords of the given
+// version and error correction level.
+func qrNumDataCodewords(version int, level QRLevel) int {
+	return qrNumRawDataModules(version)/8 -
+		qrECCPerBlock[level][version]*qrNumBlocks[level][version]
+}
+
+// qrAddECCAndInterleave splits the data codewords into blocks, appends the
+// error correction codewords of each block, and interleaves the blocks.
+func qrAddECCAndInterleave(data []byte, version int, level QRLevel) []byte {
+	numBlocks := qrNumBlocks[level][version]
+	blockECCLen := qrECCPerBlock[level][version]
+	rawCodewords := qrNumRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	// Each of the long blocks holds one more data codeword than the short
+	// ones. We'll pad the short blocks with a dummy codeword at that
+	// position, so all blocks can be interleaved alike.
+	divisor := qrReedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		datLen := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			datLen++
+		}
+		dat := data[k : k+datLen]
+		k += datLen
+
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, dat...)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		ecc := qrReedSolomonRemainder(dat, divisor)
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			isDummy := i == shortBlockLen-blockECCLen &&
+				j < numShortBlocks
+			if !isDummy {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	return result
+}
+
+// qrReedSolomonDivisor returns the generator polynomial of the given degree,
+// with its coefficients from highest to lowest power, omitting the leading
+// coefficient of one.
+func qrReedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrGFMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// qrReedSolomonRemainder returns the error correction codewords of the passed
+// data for the given generator polynomial.
+func qrReedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= qrGFMultiply(coef, factor)
+		}
+	}
+
+	return result
+}
+
+// qrGFMultiply multiplies two elements of GF(2^8) modulo the QR code
+// polynomial x^8 + x^4 + x^3 + x^2 + 1.
+func qrGFMultiply(x, y byte) byte {
+	var z uint32
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= uint32((y>>uint(i))&1) * uint32(x)
+	}
+
+	return byte(z)
+}
+
+// setFunctionModule colors the module at the given column and row, and marks
+// it as part of a function pattern.
+func (q *QRCode) setFunctionModule(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns draws the timing, finder and alignment patterns, and
+// reserves the modules of the format and version information.
+func (q *QRCode) drawFunctionPatterns(level QRLevel) {
+	for i := 0; i < q.Size; i++ {
+		q.setFunctionModule(6, i, i%2 == 0)
+		q.setFunctionModule(i, 6, i%2 == 0)
+	}
+
+	q.drawFinderPattern(3, 3)
+	q.drawFinderPattern(q.Size-4, 3)
+	q.drawFinderPattern(3, q.Size-4)
+
+	// The alignment patterns are placed on a grid, skipping the three
+	// corners that hold the finder patterns.
+	positions := q.alignmentPatternPositions()
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) ||
+				(i == last && j == 0) {
+
+				continue
+			}
+			q.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// The format bits are drawn here only to reserve their modules, they
+	// are overwritten once the mask is chosen.
+	q.drawFormatBits(level, 0)
+	q.drawVersion()
+}
+
+// drawFinderPattern draws a finder pattern, including its separator, centered
+// at the given module.
+func (q *QRCode) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= q.Size || yy >= q.Size {
+				continue
+			}
+
+			dist := maxInt(absInt(dx), absInt(dy))
+			q.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern draws an alignment pattern centered at the given
+// module.
+func (q *QRCode) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			dist := maxInt(absInt(dx), absInt(dy))
+			q.setFunctionModule(x+dx, y+dy, dist != 1)
+		}
+	}
+}
+
+// alignmentPatternPositions returns the coordinates of the rows and columns
+// that alignment patterns are centered on.
+func (q *QRCode) alignmentPatternPositions() []int {
+	if q.Version == 1 {
+		return nil
+	}
+
+	numAlign := q.Version/7 + 2
+	step := (q.Version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, q.Size-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+
+	return positions
+}
+
+// drawFormatBits draws both copies of the format information, which encodes
+// the error correction level and mask, protected by a BCH code.
+func (q *QRCode) drawFormatBits(level QRLevel, mask int) {
+	data := qrFormatBits[level]<<3 | uint32(mask)
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 == 1
+	}
+
+	// The first copy surrounds the top left finder pattern.
+	for i := 0; i <= 5; i++ {
+		q.setFunctionModule(8, i, bit(i))
+	}
+	q.setFunctionModule(8, 7, bit(6))
+	q.setFunctionModule(8, 8, bit(7))
+	q.setFunctionModule(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunctionModule(14-i, 8, bit(i))
+	}
+
+	// The second copy is split between the other two finder patterns,
+	// next to the module that's always dark.
+	for i := 0; i < 8; i++ {
+		q.setFunctionModule(q.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunctionModule(8, q.Size-15+i, bit(i))
+	}
+	q.setFunctionModule(8, q.Size-8, true)
+}
+
+// drawVersion draws both copies of the version information, which is only
+// present from version 7 onwards.
+func (q *QRCode) drawVersion() {
+	if q.Version < 7 {
+		return
+	}
+
+	rem := uint32(q.Version)
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	bits := uint32(q.Version)<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		dark := (bits>>uint(i))&1 == 1
+		a, b := q.Size-11+i%3, i/3
+		q.setFunctionModule(a, b, dark)
+		q.setFunctionModule(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the modules that aren't part of a
+// function pattern, zigzagging through pairs of columns from the bottom right.
+func (q *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		// The vertical timing pattern is skipped entirely.
+		if right == 6 {
+			right = 5
+		}
+
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+
+				if q.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y][x] = (data[i>>3]>>
+					(7-uint(i&7)))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by the given mask pattern.
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+
+			if invert && !q.isFunction[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the QR code is to scan, following the four rules
+// of the specification: long runs of the same color, 2x2 blocks of the same
+// color, patterns resembling a finder pattern, and an unbalanced proportion
+// of dark modules.
+func (q *QRCode) penalty() int {
+	// Both the rows and columns are scored alike, so we'll read the
+	// columns through a transposed accessor.
+	lines := []func(i, j int) bool{
+		func(i, j int) bool { return q.modules[i][j] },
+		func(i, j int) bool { return q.modules[j][i] },
+	}
+
+	finderLike := []bool{
+		true, false, true, true, true, false, true,
+	}
+
+	var penalty int
+	for _, module := range lines {
+		for i := 0; i < q.Size; i++ {
+			runLen := 1
+			for j := 1; j <= q.Size; j++ {
+				if j < q.Size &&
+					module(i, j) == module(i, j-1) {
+
+					runLen++
+					continue
+				}
+				if runLen >= 5 {
+					penalty += runLen - 2
+				}
+				runLen = 1
+			}
+
+			for j := 0; j+len(finderLike) <= q.Size; j++ {
+				matches := true
+				for k, dark := range finderLike {
+					if module(i, j+k) != dark {
+						matches = false
+						break
+					}
+				}
+				if !matches {
+					continue
+				}
+
+				// The pattern only counts if it's preceded
+				// or followed by four light modules, with the
+				// quiet zone counting as light.
+				before, after := true, true
+				for k := 1; k <= 4; k++ {
+					if j-k >= 0 && module(i, j-k) {
+						before = false
+					}
+					end := j + len(finderLike) - 1 + k
+					if end < q.Size &&
+						module(i, end) {
+
+						after = false
+					}
+				}
+				if before || after {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	var dark int
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 >= q.Size || y+1 >= q.Size {
+				continue
+			}
+
+			color := q.modules[y][x]
+			if color == q.modules[y][x+1] &&
+				color == q.modules[y+1][x] &&
+				color == q.modules[y+1][x+1] {
+
+				penalty += 3
+			}
+		}
+	}
+
+	// Every 5% that the dark modules deviate from half of the code adds
+	// another 10 points.
+	total := q.Size * q.Size
+	k := (absInt(dark*20-total*10)+total-1)/total - 1
+	penalty += k * 10
+
+	return penalty
+}
+
+// absInt returns the absolute value of x.
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+// maxInt returns the larger of a and b.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,82 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// qrFileScale is the number of pixels per module of the QR code PNG
+	// files, which keeps even large codes crisp when printed.
+	qrFileScale = 8
+)
+
+// qrFileName returns the name of the QR code PNG file of the key at the
+// passed derivation path, e.g. m_84h_0h_0h_0_0.png for m/84'/0'/0'/0/0.
+func qrFileName(path string) string {
+	name := strings.NewReplacer("/", "_", "'", "h").Replace(path)
+	return name + ".png"
+}
+
+// writeQRFile encodes the passed data as a QR code, and writes it as a PNG
+// file with the given name into dir.
+func writeQRFile(dir, name, data string) error {
+	// The codes are meant to be printed, where they may get scuffed or
+	// folded, so we'll trade some capacity for a higher level of error
+	// correction.
+	q, err := EncodeQR([]byte(data), QRLevelQ)
+	if err != nil {
+		return fmt.Errorf("unable to encode QR code of %v: %w", name,
+			err)
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to create QR code file: %w", err)
+	}
+
+	if err := png.Encode(f, q.Image(qrFileScale)); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write QR code file %v: %w", path,
+			err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write QR code file %v: %w", path,
+			err)
+	}
+
+	return nil
+}
+
+// writeQRFiles writes a QR code PNG file for each derived address, and each
+// account xpub, into dir, creating it if it doesn't exist yet.
+func writeQRFiles(dir string, res *recoveryResult) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create QR code directory: %w", err)
+	}
+
+	for _, a := range res.addrs {
+		name := qrFileName(a.path.String())
+		err := writeQRFile(dir, name, a.addr.EncodeAddress())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, x := range res.xpubs {
+		if x.xpub == "" {
+			continue
+		}
+
+		name := qrFileName(x.path.AccountPath())
+		if err := writeQRFile(dir, name, x.xpub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,1015 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// parallelDeriveThreshold is the number of addresses per branch from
+	// which their derivation is spread across all CPUs.
+	parallelDeriveThreshold = 1000
+)
+
+// branchSelections maps each of the accepted branch selections to the set of
+// branches that will be derived.
+var branchSelections = map[string][]uint32{
+	"external": {ExternalBranch},
+	"internal": {InternalBranch},
+	"both":     {ExternalBranch, InternalBranch},
+}
+
+// branchNames maps each branch to the name used when printing its section.
+var branchNames = map[uint32]string{
+	ExternalBranch: "External",
+	InternalBranch: "Internal (change)",
+}
+
+// addrType describes one of the types of addresses derived from the seed.
+type addrType struct {
+	// name is the short name of the address type.
+	name string
+
+	// scope is the name of the BIP that defines the key scope.
+	scope string
+
+	// descriptorFmt is the format of the output descriptor of the address
+	// type, with a placeholder for the key expression.
+	descriptorFmt string
+
+	// purpose is the BIP0043 purpose of the key scope that the addresses
+	// are derived from.
+	purpose uint32
+
+	// compressed is true if the address commits to the compressed
+	// serialization of its key.
+	compressed bool
+
+	// keyToAddr converts a derived key into an address of this type.
+	keyToAddr func(*btcec.PublicKey, *chaincfg.Params) (btcutil.Address,
+		error)
+}
+
+// addrTypes returns the set of address types derived from the seed, in the
+// order they're printed.
+func addrTypes(cfg *Config) []addrType {
+	// Segwit outputs require compressed keys, so only the legacy p2pkh
+	// address can be computed from the uncompressed key.
+	legacyCompressed := !cfg.LegacyUncompressed && !cfg.Uncompressed
+
+	return []addrType{
+		{
+			name:          "p2wkh",
+			scope:         "BIP84",
+			descriptorFmt: "wpkh(%s)",
+			purpose:       waddrmgr.KeyScopeBIP0084.Purpose,
+			compressed:    true,
+			keyToAddr:     KeyToP2wkhAddr,
+		},
+		{
+			name:          "np2wkh",
+			scope:         "BIP49",
+			descriptorFmt: "sh(wpkh(%s))",
+			purpose:       waddrmgr.KeyScopeBIP0049Plus.Purpose,
+			compressed:    true,
+			keyToAddr:     KeyToNp2wkhAddr,
+		},
+		{
+			name:          "p2tr",
+			scope:         "BIP86",
+			descriptorFmt: "tr(%s)",
+			purpose:       BIP0086Purpose,
+			compressed:    true,
+			keyToAddr:     KeyToP2trAddr,
+		},
+		{
+			name:          "p2pkh",
+			scope:         "BIP44",
+			descriptorFmt: "pkh(%s)",
+			purpose:       waddrmgr.KeyScopeBIP0044.Purpose,
+			compressed:    legacyCompressed,
+			keyToAddr: func(key *btcec.PublicKey,
+				params *chaincfg.Params) (btcutil.Address,
+				error) {
+
+				return KeyToP2pkhAddr(
+					key, legacyCompressed, params,
+				)
+			},
+		},
+	}
+}
+
+// selectedAddrTypes returns the address types selected by the AddrTypes of
+// the passed config, in the order of addrTypes.
+func selectedAddrTypes(cfg *Config) []addrType {
+	if len(cfg.AddrTypes) == 0 {
+		return addrTypes(cfg)
+	}
+
+	selected := make(map[string]bool, len(cfg.AddrTypes))
+	for _, name := range cfg.AddrTypes {
+		selected[name] = true
+	}
+
+	var types []addrType
+	for _, t := range addrTypes(cfg) {
+		if selected[t.name] {
+			types = append(types, t)
+		}
+	}
+
+	return types
+}
+
+// validateAddrTypes checks that each of the AddrTypes of the passed config
+// names one of the address types.
+func validateAddrTypes(cfg *Config) error {
+	known := make(map[string]bool)
+	for _, t := range addrTypes(cfg) {
+		known[t.name] = true
+	}
+
+	for _, name := range cfg.AddrTypes {
+		if !known[name] {
+			return fmt.Errorf("unknown address type %q, expected "+
+				"one of: p2wkh, np2wkh, p2tr, p2pkh", name)
+		}
+	}
+
+	return nil
+}
+
+// deriveAddr derives the address of the given type at the passed index of a
+// branch key. If withWIF is true, then the WIF encoding of the private key of
+// the address is returned as well.
+func deriveAddr(branchKey *hdkeychain.ExtendedKey, t addrType, index uint32,
+	params *chaincfg.Params, withWIF bool) (btcutil.Address, string,
+	error) {
+
+	child, err := branchKey.Child(index)
+	if err != nil {
+		return nil, "", err
+	}
+	defer child.Zero()
+
+	key, err := child.ECPubKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	addr, err := t.keyToAddr(key, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !withWIF {
+		return addr, "", nil
+	}
+
+	privKey, err := child.ECPrivKey()
+	if err != nil {
+		return nil, "", err
+	}
+	defer ZeroPrivKey(privKey)
+
+	wif, err := btcutil.NewWIF(privKey, params, t.compressed)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return addr, wif.String(), nil
+}
+
+// Run deciphers the seed of the passed config, derives the node key, accounts
+// and addresses it's configured to, and writes them to w in the configured
+// format.
+func Run(cfg Config, w io.Writer) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	var (
+		res *recoveryResult
+		err error
+	)
+	if cfg.WatchXpub != "" {
+		res, err = recoverWatchOnly(&cfg)
+	} else {
+		res, err = recoverSeed(&cfg)
+	}
+	if err != nil {
+		return err
+	}
+	defer res.zero()
+
+	// When scanning, the balances were already looked up to tell which
+	// addresses are used.
+	if cfg.Esplora != "" && !cfg.Scan {
+		if err := lookupBalances(&cfg, res.addrs); err != nil {
+			return err
+		}
+	}
+
+	if cfg.QRDir != "" {
+		if err := writeQRFiles(cfg.QRDir, res); err != nil {
+			return err
+		}
+	}
+
+	switch cfg.Format {
+	case FormatJSON:
+		err = printJSON(w, res, &cfg)
+
+	case FormatCSV:
+		err = printCSV(w, res, &cfg)
+
+	case FormatTable:
+		err = printTable(w, res, &cfg)
+
+	case FormatElectrum:
+		err = printElectrum(w, res)
+
+	case FormatSparrow:
+		err = printSparrow(w, res, &cfg)
+
+	case FormatColdcard:
+		err = printColdcard(w, res, &cfg)
+
+	case FormatBitcoind:
+		// We'll have bitcoind watch all the addresses we derived, as
+		// well as a gap limit's worth beyond them.
+		err = printBitcoind(
+			w, res, cfg.FirstIndex,
+			cfg.FirstIndex+res.numAddrs-1+cfg.GapLimit,
+		)
+
+	default:
+		err = printText(w, res, &cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to print results: %w", err)
+	}
+
+	return nil
+}
+
+// recoverSeed deciphers the seed of the passed config, and derives everything
+// that should be printed from it.
+func recoverSeed(cfg *Config) (*recoveryResult, error) {
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	// The passphrase isn't needed again once the seed is deciphered, and
+	// neither is the entropy once we've derived the root key from it, so
+	// we'll clear them as soon as possible.
+	cipherSeed, err := cfg.Mnemonic.ToCipherSeed(cfg.Passphrase)
+	ZeroBytes(cfg.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt cipher seed: %w",
+			err)
+	}
+	defer ZeroCipherSeed(cipherSeed)
+
+	// The checksum of the mnemonic doesn't catch all corruption, so a
+	// nonsensical birthday is a last hint that something is off.
+	if err := cfg.CheckBirthday(cipherSeed.BirthdayTime()); err != nil {
+		return nil, err
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+
+	// The fingerprint identifies the seed without revealing any keys, so
+	// it's always included, and it's also part of every descriptor.
+	fingerprint, err := MasterFingerprint(rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute master fingerprint: "+
+			"%w", err)
+	}
+
+	cfg.logf(VerbositySteps, "Deciphered seed of internal version %v "+
+		"with birthday %v", cipherSeed.InternalVersion,
+		cipherSeed.BirthdayTime())
+	cfg.logf(VerbosityFingerprints, "Fingerprint of m: %x",
+		fingerprint[:])
+
+	// All keys of this run are derived through the cache, so that each
+	// account key is only derived once, and each step is logged.
+	keyCache := newAccountKeyCache(rootKey, params.CoinType)
+	keyCache.cfg = cfg
+	defer keyCache.zero()
+
+	// Just like lnd, we'll derive the node key using the coin type of the
+	// selected network, so the key on any of the test networks will differ
+	// from the one on mainnet.
+	nodePub, err := keyCache.firstKey(
+		keychain.BIP0043Purpose, keychain.KeyFamilyNodeKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive node key: %w", err)
+	}
+
+	res := &recoveryResult{
+		mnemonicVersion: MnemonicVersion(cfg.Mnemonic[:]),
+		birthday:        cipherSeed.BirthdayTime(),
+		birthdayHeight:  EstimateBlockHeight(cipherSeed.BirthdayTime()),
+		internalVersion: cipherSeed.InternalVersion,
+		fingerprint:     fingerprint,
+		nodePub:         nodePub,
+		nodePath: KeyPath{
+			Purpose:  keychain.BIP0043Purpose,
+			CoinType: params.CoinType,
+			Account:  uint32(keychain.KeyFamilyNodeKey),
+			Branch:   ExternalBranch,
+		},
+	}
+	if cfg.ShowMnemonic {
+		res.mnemonic = &cfg.Mnemonic
+	}
+	if cfg.ShowEntropy {
+		res.entropy = make([]byte, len(cipherSeed.Entropy))
+		copy(res.entropy, cipherSeed.Entropy[:])
+	}
+	if cfg.BIP39 {
+		res.bip39, err = EntropyToBIP39(cipherSeed.Entropy[:])
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode BIP39 "+
+				"mnemonic: %w", err)
+		}
+	}
+
+	// The keys of all families are derived the same way as the node key,
+	// which is just one of them.
+	if cfg.KeyFamilies {
+		for _, family := range KeyFamilies {
+			pub, err := keyCache.firstKey(
+				keychain.BIP0043Purpose, family,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("unable to derive key "+
+					"of family %d: %w", family, err)
+			}
+
+			res.familyKeys = append(res.familyKeys, familyKey{
+				family: family,
+				path: KeyPath{
+					Purpose:  keychain.BIP0043Purpose,
+					CoinType: params.CoinType,
+					Account:  uint32(family),
+					Branch:   ExternalBranch,
+				},
+				pub: pub,
+			})
+		}
+	}
+
+	if cfg.KeyLocator != nil {
+		res.locatorKey, err = deriveLocatorKey(
+			keyCache, *cfg.KeyLocator, cfg.KeyLocatorPriv,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// lnd's watchtower client hands out the indexes of its session keys
+	// from a database sequence, which starts at one.
+	for i := uint32(1); i <= cfg.TowerSessions; i++ {
+		loc := keychain.KeyLocator{
+			Family: keychain.KeyFamilyTowerSession,
+			Index:  i,
+		}
+		key, err := deriveLocatorKey(keyCache, loc, false)
+		if err != nil {
+			return nil, err
+		}
+		res.towerSessionKeys = append(res.towerSessionKeys, key)
+	}
+	if cfg.TowerSessions > 0 {
+		loc := keychain.KeyLocator{Family: keychain.KeyFamilyTowerID}
+		res.towerIDKey, err = deriveLocatorKey(keyCache, loc, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.SCB != nil {
+		res.channelBackups, err = decryptChannelBackups(
+			keyCache, cfg.SCB,
+		)
+		if err != nil {
+			return nil, err
+		}
+		res.numBackupFiles++
+	}
+	if cfg.SCBDir != "" {
+		err := decryptChannelBackupDir(keyCache, cfg.SCBDir, res)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// For a bulk import, we'll derive a gap limit's worth of additional
+	// addresses on each branch.
+	res.numAddrs = cfg.Count
+	if cfg.BulkImport {
+		res.numAddrs += cfg.GapLimit
+	}
+
+	// Each account is derived in turn, so the results end up grouped by
+	// account.
+	for _, account := range cfg.accounts() {
+		err := deriveAccount(cfg, params, keyCache, res, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// deriveAccount derives the account keys of each selected address type at the
+// passed account index, and adds their extended keys, descriptors and
+// addresses to the result.
+func deriveAccount(cfg *Config, params *NetParams, keyCache *accountKeyCache,
+	res *recoveryResult, account uint32) error {
+
+	// For each address type, we'll derive the account key only once, and
+	// then reuse it to derive all the requested branches and indexes.
+	types := selectedAddrTypes(cfg)
+	accountKeys := make([]*hdkeychain.ExtendedKey, len(types))
+	for i, t := range types {
+		var err error
+		accountKeys[i], err = keyCache.accountKey(
+			t.purpose, keychain.KeyFamily(account),
+		)
+		if err != nil {
+			return fmt.Errorf("unable to derive %v account "+
+				"key: %w", t.name, err)
+		}
+
+		// The Coldcard summary is made up of the account xpubs, so
+		// we'll always encode them for that format.
+		coldcard := cfg.Format == FormatColdcard
+		if !cfg.Xpub && !cfg.Xprv && !coldcard {
+			continue
+		}
+
+		extKey := accountXpub{
+			addrType: t,
+			path: KeyPath{
+				Purpose:  t.purpose,
+				CoinType: params.CoinType,
+				Account:  account,
+			},
+		}
+
+		// The extended keys are encoded with the version bytes of the
+		// selected network.
+		if cfg.Xpub || coldcard {
+			accountPub, err := accountKeys[i].Neuter()
+			if err != nil {
+				return fmt.Errorf("unable to neuter %v "+
+					"account key: %w", t.name, err)
+			}
+			extKey.xpub = accountPub.String()
+
+			if cfg.Slip132 || coldcard {
+				extKey.xpub, err = Slip132Encode(
+					extKey.xpub, t.purpose, params.Params,
+				)
+				if err != nil {
+					return fmt.Errorf("unable to "+
+						"encode %v xpub: %w", t.name,
+						err)
+				}
+			}
+		}
+		if cfg.Xprv {
+			extKey.xprv = accountKeys[i].String()
+		}
+
+		res.xpubs = append(res.xpubs, extKey)
+	}
+
+	// The Electrum wallet only holds the BIP0084 account, with its xpub
+	// encoded as a zpub, from which Electrum detects the script type.
+	for i, t := range types {
+		if cfg.Format != FormatElectrum ||
+			t.purpose != waddrmgr.KeyScopeBIP0084.Purpose {
+
+			continue
+		}
+
+		accountPub, err := accountKeys[i].Neuter()
+		if err != nil {
+			return fmt.Errorf("unable to neuter %v account "+
+				"key: %w", t.name, err)
+		}
+		zpub, err := Slip132Encode(
+			accountPub.String(), t.purpose, params.Params,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to encode %v xpub: %w",
+				t.name, err)
+		}
+
+		res.electrumAccount = &accountXpub{
+			addrType: t,
+			path: KeyPath{
+				Purpose:  t.purpose,
+				CoinType: params.CoinType,
+				Account:  account,
+			},
+			xpub: zpub,
+		}
+	}
+
+	// The bitcoind import payload and the Sparrow wallet are made up of
+	// descriptors, so we'll build them for those formats even if they
+	// weren't requested.
+	if cfg.Descriptors || cfg.Format == FormatBitcoind ||
+		cfg.Format == FormatSparrow {
+
+		for i, t := range types {
+			accountPub, err := accountKeys[i].Neuter()
+			if err != nil {
+				return fmt.Errorf("unable to neuter %v "+
+					"account key: %w", t.name, err)
+			}
+			accountPath := KeyPath{
+				Purpose:  t.purpose,
+				CoinType: params.CoinType,
+				Account:  account,
+			}
+
+			descBranches := []uint32{
+				ExternalBranch, InternalBranch,
+			}
+			for _, b := range descBranches {
+				desc, err := BuildDescriptor(
+					t.descriptorFmt, res.fingerprint,
+					accountPath, accountPub.String(), b,
+				)
+				if err != nil {
+					return fmt.Errorf("unable to "+
+						"build %v descriptor: %w",
+						t.name, err)
+				}
+
+				res.descriptors = append(
+					res.descriptors, outputDescriptor{
+						addrType: t,
+						branch:   b,
+						desc:     desc,
+					},
+				)
+			}
+		}
+	}
+
+	addrs, err := deriveAddrs(
+		cfg, params, types, accountKeys, account, res.numAddrs,
+	)
+	if err != nil {
+		return err
+	}
+	res.addrs = append(res.addrs, addrs...)
+
+	return nil
+}
+
+// deriveLocatorKey derives the key identified by the passed key locator,
+// including its private key if withPriv is true.
+func deriveLocatorKey(keyCache *accountKeyCache, loc keychain.KeyLocator,
+	withPriv bool) (*locatorKey, error) {
+
+	accountKey, err := keyCache.accountKey(
+		keychain.BIP0043Purpose, loc.Family,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive key family %d: %w",
+			loc.Family, err)
+	}
+
+	extKey, err := deriveLocatorChild(accountKey, loc.Index)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive key at family %d "+
+			"index %d: %w", loc.Family, loc.Index, err)
+	}
+	defer extKey.Zero()
+
+	key := &locatorKey{
+		loc: loc,
+		path: KeyPath{
+			Purpose:  keychain.BIP0043Purpose,
+			CoinType: keyCache.coinType,
+			Account:  uint32(loc.Family),
+			Branch:   ExternalBranch,
+			Index:    loc.Index,
+		},
+	}
+
+	key.pub, err = extKey.ECPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive public key: %w", err)
+	}
+
+	if withPriv {
+		key.priv, err = extKey.ECPrivKey()
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive private "+
+				"key: %w", err)
+		}
+	}
+
+	return key, nil
+}
+
+// decryptChannelBackups decrypts the passed packed static channel backup with
+// the key derived from the seed, and returns the channels it contains.
+func decryptChannelBackups(keyCache *accountKeyCache,
+	packed []byte) ([]ChannelBackup, error) {
+
+	backupPub, err := keyCache.firstKey(
+		keychain.BIP0043Purpose, keychain.KeyFamilyStaticBackup,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive backup key: %w", err)
+	}
+	key := scbKeyFromPub(backupPub)
+
+	plaintext, err := DecryptSCB(packed, key)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := ParseMultiBackup(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse channel backup: %w",
+			err)
+	}
+
+	// We'll return an empty rather than nil slice for a backup without
+	// any channels, so it's still reported.
+	if backups == nil {
+		backups = []ChannelBackup{}
+	}
+
+	return backups, nil
+}
+
+// decryptChannelBackupDir decrypts every static channel backup file found in
+// the passed directory or any of its subdirectories, and merges the channels
+// they contain into the result. Each channel is only included once, no matter
+// how many backups contain it. Files that can't be decrypted are recorded as
+// skipped, rather than failing the recovery.
+func decryptChannelBackupDir(keyCache *accountKeyCache, dir string,
+	res *recoveryResult) error {
+
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo,
+		err error) error {
+
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, scbFileSuffix) {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to search for channel backups: %w",
+			err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no channel backup files ending in %v found "+
+			"in %v", scbFileSuffix, dir)
+	}
+
+	seen := make(map[wire.OutPoint]bool)
+	for _, c := range res.channelBackups {
+		seen[c.ChannelPoint] = true
+	}
+
+	// Even if none of the files can be decrypted, we'll still report the
+	// empty set of channels along with the skipped files.
+	if res.channelBackups == nil {
+		res.channelBackups = []ChannelBackup{}
+	}
+
+	for _, path := range paths {
+		packed, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read channel backup: %w",
+				err)
+		}
+
+		backups, err := decryptChannelBackups(keyCache, packed)
+		if err != nil {
+			res.skippedBackups = append(
+				res.skippedBackups, skippedBackup{
+					path: path,
+					err:  err,
+				},
+			)
+			continue
+		}
+		res.numBackupFiles++
+
+		for _, c := range backups {
+			if seen[c.ChannelPoint] {
+				continue
+			}
+			seen[c.ChannelPoint] = true
+			res.channelBackups = append(res.channelBackups, c)
+		}
+	}
+
+	return nil
+}
+
+// deriveAddrs derives numAddrs addresses on each configured branch of the
+// passed account keys, one for each of the address types. If scanning was
+// requested, then the used addresses are derived instead, until a gap limit's
+// worth of unused ones is found. The account keys may be neutered, as long as
+// no private keys were requested.
+//
+// The addresses are returned ordered by branch, with the external branch
+// first, and then by address type, in the order of the passed types.
+func deriveAddrs(cfg *Config, params *NetParams, types []addrType,
+	accountKeys []*hdkeychain.ExtendedKey, account,
+	numAddrs uint32) ([]derivedAddr, error) {
+
+	var (
+		client *EsploraClient
+		err    error
+	)
+	if cfg.Scan {
+		client, err = newEsploraClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	typeAddrs := make([][]derivedAddr, len(types))
+	errs := make([]error, len(types))
+	deriveType := func(j int) {
+		typeAddrs[j], errs[j] = deriveTypeAddrs(
+			cfg, params, client, types[j], accountKeys[j], account,
+			numAddrs,
+		)
+	}
+
+	// Each address type is derived from its own account key, so the
+	// types can be derived concurrently. That's not the case for a scan
+	// though, as the Esplora client paces its requests one at a time.
+	if cfg.Scan || len(types) == 1 {
+		for j := range types {
+			deriveType(j)
+			if errs[j] != nil {
+				return nil, errs[j]
+			}
+		}
+	} else {
+		var wg sync.WaitGroup
+		for j := range types {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				deriveType(j)
+			}(j)
+		}
+		wg.Wait()
+
+		// We'll return the error of the first failing type, just like
+		// the serial derivation would.
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var addrs []derivedAddr
+	for _, b := range branchSelections[cfg.Branch] {
+		for j := range types {
+			for _, addr := range typeAddrs[j] {
+				if addr.path.Branch == b {
+					addrs = append(addrs, addr)
+				}
+			}
+		}
+	}
+
+	return addrs, nil
+}
+
+// deriveTypeAddrs derives the addresses of a single address type on each
+// configured branch of its account key, with their full paths filled in. The
+// account key must not be shared with a concurrent derivation, as a private
+// key memoizes its public key on the first child derivation.
+func deriveTypeAddrs(cfg *Config, params *NetParams, client *EsploraClient,
+	t addrType, accountKey *hdkeychain.ExtendedKey, account,
+	numAddrs uint32) ([]derivedAddr, error) {
+
+	var addrs []derivedAddr
+	for _, b := range branchSelections[cfg.Branch] {
+		branchPath := fmt.Sprintf("%v/%d", KeyPath{
+			Purpose:  t.purpose,
+			CoinType: params.CoinType,
+			Account:  account,
+		}.AccountPath(), b)
+
+		// The branch key is derived once, leaving only the final child
+		// derivation per address.
+		cfg.logf(VerbositySteps, "Deriving %v branch key %v", t.name,
+			branchPath)
+		branchKey, err := accountKey.Child(b)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive %v branch "+
+				"key: %w", t.name, err)
+		}
+		cfg.logFingerprint(branchPath, branchKey)
+
+		var branchAddrs []derivedAddr
+		if cfg.Scan {
+			cfg.logf(VerbositySteps, "Scanning %v for used %v "+
+				"addresses", branchPath, t.name)
+			branchAddrs, err = scanBranchAddrs(
+				client, branchKey, t, cfg.GapLimit,
+				params.Params, cfg.WIF, cfg.Progress,
+			)
+		} else {
+			cfg.logf(VerbositySteps, "Deriving %v child indexes "+
+				"%v through %v of %v", t.name, cfg.FirstIndex,
+				cfg.FirstIndex+numAddrs-1, branchPath)
+			branchAddrs, err = deriveBranchAddrs(
+				branchKey, t, cfg.FirstIndex, numAddrs,
+				params.Params, cfg.WIF,
+			)
+		}
+		if err == nil && cfg.Descriptors &&
+			t.purpose == BIP0086Purpose {
+
+			err = addInternalKeys(branchKey, branchAddrs)
+		}
+		branchKey.Zero()
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the index is known within the branch, so we'll fill in
+		// the rest of the path.
+		for _, addr := range branchAddrs {
+			addr.path = KeyPath{
+				Purpose:  t.purpose,
+				CoinType: params.CoinType,
+				Account:  account,
+				Branch:   b,
+				Index:    addr.path.Index,
+			}
+			cfg.logf(VerbosityFingerprints, "Derived %v key %v",
+				t.name, addr.path)
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs, nil
+}
+
+// addInternalKeys fills in the x-only internal key of each of the passed p2tr
+// addresses derived from the branch key, as their output key is tweaked and
+// can't be mapped back to it.
+func addInternalKeys(branchKey *hdkeychain.ExtendedKey,
+	addrs []derivedAddr) error {
+
+	for i := range addrs {
+		child, err := branchKey.Child(addrs[i].path.Index)
+		if err != nil {
+			return err
+		}
+		key, err := child.ECPubKey()
+		child.Zero()
+		if err != nil {
+			return err
+		}
+
+		addrs[i].internalKey = serializeXOnly(key.X)
+	}
+
+	return nil
+}
+
+// deriveBranchAddrs derives the addresses of the given type at the numAddrs
+// indexes of a branch key starting at firstIndex. As each child derivation is
+// CPU bound, large batches are fanned out across a worker per CPU, with the
+// results collected by index to keep the output deterministic.
+func deriveBranchAddrs(branchKey *hdkeychain.ExtendedKey, t addrType,
+	firstIndex, numAddrs uint32, params *chaincfg.Params,
+	withWIF bool) ([]derivedAddr, error) {
+
+	return deriveBranchAddrsWorkers(
+		branchKey, t, firstIndex, numAddrs, params, withWIF,
+		runtime.NumCPU(),
+	)
+}
+
+// deriveBranchAddrsWorkers is deriveBranchAddrs with the number of workers
+// that large batches are fanned out across given explicitly. A single worker
+// derives the addresses serially.
+func deriveBranchAddrsWorkers(branchKey *hdkeychain.ExtendedKey, t addrType,
+	firstIndex, numAddrs uint32, params *chaincfg.Params, withWIF bool,
+	numWorkers int) ([]derivedAddr, error) {
+
+	addrs := make([]derivedAddr, numAddrs)
+	errs := make([]error, numAddrs)
+	deriveIndex := func(i uint32) {
+		index := firstIndex + i
+		addr, wif, err := deriveAddr(
+			branchKey, t, index, params, withWIF,
+		)
+		if err != nil {
+			errs[i] = fmt.Errorf("unable to derive %v addr at "+
+				"index %v: %w", t.name, index, err)
+			return
+		}
+
+		addrs[i] = derivedAddr{
+			addrType: t,
+			path:     KeyPath{Index: index},
+			addr:     addr,
+			wif:      wif,
+		}
+	}
+
+	if numAddrs < parallelDeriveThreshold || numWorkers <= 1 {
+		for i := uint32(0); i < numAddrs; i++ {
+			deriveIndex(i)
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+		}
+
+		return addrs, nil
+	}
+
+	// A private branch key memoizes its public key the first time it's
+	// needed for a child derivation, so we'll do that up front to ensure
+	// the workers only ever read from it.
+	if _, err := branchKey.ECPubKey(); err != nil {
+		return nil, fmt.Errorf("unable to derive %v branch pubkey: %w",
+			t.name, err)
+	}
+
+	indexes := make(chan uint32)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				deriveIndex(i)
+			}
+		}()
+	}
+	for i := uint32(0); i < numAddrs; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// We'll return the error of the lowest failing index, just like the
+	// serial derivation would.
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return addrs, nil
+}
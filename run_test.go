@@ -0,0 +1,94 @@
+package aezeedcheck
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// benchNumAddrs is the number of addresses the benchmarks derive per branch,
+// which is large enough for their derivation to be spread across all CPUs.
+const benchNumAddrs = 2 * parallelDeriveThreshold
+
+// benchBranchKey returns the external branch key of the first BIP0084 account
+// of the self-test vector, along with the p2wkh address type.
+func benchBranchKey(b *testing.B) (*hdkeychain.ExtendedKey, addrType) {
+	b.Helper()
+
+	cfg := DefaultConfig()
+	var (
+		t     addrType
+		found bool
+	)
+	for _, candidate := range addrTypes(&cfg) {
+		if candidate.name == "p2wkh" {
+			t, found = candidate, true
+		}
+	}
+	if !found {
+		b.Fatal("p2wkh address type not found")
+	}
+
+	accountKey, err := DeriveAccountKey(
+		benchRootKey(b), waddrmgr.KeyScopeBIP0084.Purpose,
+		keychain.CoinTypeBitcoin, 0,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	branchKey, err := accountKey.Child(ExternalBranch)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return branchKey, t
+}
+
+// BenchmarkDeriveBranchAddrs measures the derivation of a batch of p2wkh
+// addresses from a branch key.
+func BenchmarkDeriveBranchAddrs(b *testing.B) {
+	branchKey, t := benchBranchKey(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := deriveBranchAddrs(
+			branchKey, t, 0, benchNumAddrs,
+			&chaincfg.MainNetParams, false,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDeriveBranchAddrsWorkers compares the serial derivation of a batch
+// of p2wkh addresses with the one spread across a worker per CPU.
+func BenchmarkDeriveBranchAddrsWorkers(b *testing.B) {
+	branchKey, t := benchBranchKey(b)
+
+	for _, bench := range []struct {
+		name       string
+		numWorkers int
+	}{
+		{name: "serial", numWorkers: 1},
+		{name: "pool", numWorkers: runtime.NumCPU()},
+	} {
+		bench := bench
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := deriveBranchAddrsWorkers(
+					branchKey, t, 0, benchNumAddrs,
+					&chaincfg.MainNetParams, false,
+					bench.numWorkers,
+				)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
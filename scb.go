@@ -0,0 +1,173 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// multiBackupVersion is the only version of the multi channel backup
+	// format known to lnd.
+	multiBackupVersion = 0
+
+	// scbFileSuffix is the suffix of the channel backup files that are
+	// searched for in a directory, like lnd's channel.backup.
+	scbFileSuffix = ".backup"
+)
+
+// ChannelBackup holds the parts of a single channel backup that identify the
+// channel it belongs to.
+type ChannelBackup struct {
+	// Version is the version of the single channel backup.
+	Version uint8
+
+	// IsInitiator is true if we opened the channel.
+	IsInitiator bool
+
+	// ChainHash is the genesis hash of the chain the channel is on.
+	ChainHash chainhash.Hash
+
+	// ChannelPoint is the funding outpoint of the channel.
+	ChannelPoint wire.OutPoint
+
+	// ShortChannelID is the encoded short channel ID of the channel.
+	ShortChannelID uint64
+
+	// RemoteNodePub is the identity key of the remote node.
+	RemoteNodePub *btcec.PublicKey
+}
+
+// SCBEncryptionKey derives the key lnd encrypts its static channel backups
+// with, which is the sha256 of the first public key of the static backup key
+// family.
+func SCBEncryptionKey(rootKey *hdkeychain.ExtendedKey,
+	coinType uint32) ([32]byte, error) {
+
+	backupPub, err := DeriveFirstKey(
+		rootKey, keychain.BIP0043Purpose, coinType,
+		keychain.KeyFamilyStaticBackup,
+	)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return scbKeyFromPub(backupPub), nil
+}
+
+// scbKeyFromPub returns the backup encryption key for the passed first public
+// key of the static backup key family.
+func scbKeyFromPub(backupPub *btcec.PublicKey) [32]byte {
+	return sha256.Sum256(backupPub.SerializeCompressed())
+}
+
+// DecryptSCB decrypts a packed static channel backup, e.g. the contents of
+// lnd's channel.backup file, with the passed encryption key. Just like lnd,
+// the backup is expected to consist of the XChaCha20-Poly1305 nonce, followed
+// by the ciphertext which is authenticated along with the nonce.
+func DecryptSCB(packed []byte, key [32]byte) ([]byte, error) {
+	if len(packed) < xchachaNonceSize {
+		return nil, fmt.Errorf("backup too short: %v bytes",
+			len(packed))
+	}
+
+	nonce := packed[:xchachaNonceSize]
+	ciphertext := packed[xchachaNonceSize:]
+
+	plaintext, err := xchacha20Poly1305Open(&key, nonce, ciphertext, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt backup, it may not "+
+			"belong to this seed: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// ParseMultiBackup parses the decrypted payload of a multi channel backup,
+// returning the channel identifying parts of each single backup it contains.
+func ParseMultiBackup(plaintext []byte) ([]ChannelBackup, error) {
+	r := bytes.NewReader(plaintext)
+
+	var header struct {
+		Version    uint8
+		NumBackups uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("unable to read header: %w", err)
+	}
+	if header.Version != multiBackupVersion {
+		return nil, fmt.Errorf("unknown multi backup version %v",
+			header.Version)
+	}
+
+	var backups []ChannelBackup
+	for i := uint32(0); i < header.NumBackups; i++ {
+		backup, err := parseSingleBackup(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse backup #%v: %w",
+				i, err)
+		}
+		backups = append(backups, *backup)
+	}
+
+	return backups, nil
+}
+
+// parseSingleBackup parses a single channel backup from the passed reader.
+// Only the fields identifying the channel are decoded, the remainder of the
+// backup is skipped, which also makes this work for any newer version that
+// only appends fields.
+func parseSingleBackup(r io.Reader) (*ChannelBackup, error) {
+	var header struct {
+		Version uint8
+		Length  uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, header.Length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	var fields struct {
+		IsInitiator    bool
+		ChainHash      chainhash.Hash
+		FundingTxid    chainhash.Hash
+		FundingIndex   uint16
+		ShortChannelID uint64
+		RemoteNodePub  [33]byte
+	}
+	err := binary.Read(bytes.NewReader(body), binary.BigEndian, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	remotePub, err := btcec.ParsePubKey(
+		fields.RemoteNodePub[:], btcec.S256(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote node key: %w", err)
+	}
+
+	return &ChannelBackup{
+		Version:     header.Version,
+		IsInitiator: fields.IsInitiator,
+		ChainHash:   fields.ChainHash,
+		ChannelPoint: wire.OutPoint{
+			Hash:  fields.FundingTxid,
+			Index: uint32(fields.FundingIndex),
+		},
+		ShortChannelID: fields.ShortChannelID,
+		RemoteNodePub:  remotePub,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package aezeedcheck
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// schnorrSigSize is the size of a BIP0340 Schnorr signature.
+const schnorrSigSize = 64
+
+// serializeScalar serializes the passed scalar as a 32-byte big-endian
+// integer.
+func serializeScalar(k *big.Int) []byte {
+	return serializeXOnly(k)
+}
+
+// taprootTweakPrivKey applies the BIP0086 taproot tweak with an empty merkle
+// root to the passed internal private key, returning the private key of the
+// output key that taprootOutputKey computes from its public key.
+func taprootTweakPrivKey(internalKey *btcec.PrivateKey) *big.Int {
+	curve := btcec.S256()
+
+	// The tweak is added to the private key of the even y coordinate
+	// variant of the internal key, as that's what its x-only
+	// serialization refers to.
+	d := new(big.Int).Set(internalKey.D)
+	if internalKey.PubKey().Y.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+
+	tweak := taggedHash(
+		"TapTweak", serializeXOnly(internalKey.PubKey().X),
+	)
+	d.Add(d, new(big.Int).SetBytes(tweak[:]))
+	d.Mod(d, curve.N)
+
+	return d
+}
+
+// schnorrSign creates a BIP0340 Schnorr signature over the passed 32-byte
+// message with the private key d, using fresh auxiliary randomness to derive
+// the nonce.
+func schnorrSign(d *big.Int, msg []byte) ([schnorrSigSize]byte, error) {
+	var auxRand [32]byte
+	if _, err := rand.Read(auxRand[:]); err != nil {
+		return [schnorrSigSize]byte{}, err
+	}
+
+	return schnorrSignAux(d, msg, auxRand[:])
+}
+
+// schnorrSignAux creates a BIP0340 Schnorr signature over the passed 32-byte
+// message with the private key d, deriving the nonce from the given auxiliary
+// randomness.
+func schnorrSignAux(d *big.Int, msg,
+	auxRand []byte) ([schnorrSigSize]byte, error) {
+
+	var sig [schnorrSigSize]byte
+	curve := btcec.S256()
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return sig, errors.New("invalid private key")
+	}
+
+	// The public key is x-only, so the private key is negated if its
+	// public key has an odd y coordinate.
+	px, py := curve.ScalarBaseMult(serializeScalar(d))
+	d = new(big.Int).Set(d)
+	if py.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+	pxBytes := serializeXOnly(px)
+
+	// The nonce is derived from the private key masked with the hashed
+	// auxiliary randomness, the public key and the message.
+	t := serializeScalar(d)
+	auxHash := taggedHash("BIP0340/aux", auxRand)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+	nonceHash := taggedHash("BIP0340/nonce", t, pxBytes, msg)
+	ZeroBytes(t)
+
+	k := new(big.Int).SetBytes(nonceHash[:])
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return sig, errors.New("derived nonce is zero")
+	}
+
+	rx, ry := curve.ScalarBaseMult(serializeScalar(k))
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+	rxBytes := serializeXOnly(rx)
+
+	challenge := taggedHash("BIP0340/challenge", rxBytes, pxBytes, msg)
+	e := new(big.Int).SetBytes(challenge[:])
+	e.Mod(e, curve.N)
+
+	// s = k + e*d mod n
+	s := e.Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	copy(sig[:32], rxBytes)
+	copy(sig[32:], serializeScalar(s))
+
+	return sig, nil
+}
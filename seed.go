@@ -0,0 +1,79 @@
+package aezeedcheck
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// bitsPerWord is the number of bits each word of a mnemonic encodes.
+const bitsPerWord = 11
+
+// MnemonicVersion decodes the version of the enciphered cipher seed encoded by
+// the passed words. The version is the first byte of the enciphered seed, so
+// it's made up of the upper 8 of the 11 bits the first word encodes.
+func MnemonicVersion(words []string) uint8 {
+	return uint8(wordIndex[words[0]] >> (bitsPerWord - 8))
+}
+
+// VerifyChecksum checks that the checksum of the enciphered seed encoded by
+// the passed words is valid, which doesn't require the passphrase. If it is,
+// then the words were input correctly, so a failure to decipher the seed can
+// only be caused by a wrong passphrase.
+func VerifyChecksum(words []string) error {
+	// We'll pack the 11 bits encoded by each word back into the
+	// enciphered seed. Words that aren't part of the word list must have
+	// been rejected by the caller already.
+	var (
+		cipherText [aezeed.EncipheredCipherSeedSize]byte
+		bitPos     int
+	)
+	for _, word := range words {
+		index := wordIndex[word]
+		for i := bitsPerWord - 1; i >= 0; i-- {
+			if index>>uint(i)&1 == 1 {
+				cipherText[bitPos/8] |= 0x80 >> uint(bitPos%8)
+			}
+			bitPos++
+		}
+	}
+
+	if cipherText[0] != aezeed.CipherSeedVersion {
+		return fmt.Errorf("unsupported mnemonic version %v",
+			cipherText[0])
+	}
+
+	// The checksum is a CRC32 over everything before it, which includes
+	// the version, the enciphered seed and the salt.
+	checksumOffset := len(cipherText) - crc32.Size
+	checksum := crc32.Checksum(
+		cipherText[:checksumOffset], crc32.MakeTable(crc32.Castagnoli),
+	)
+	if checksum != binary.BigEndian.Uint32(cipherText[checksumOffset:]) {
+		return errors.New("checksum mismatch, one or more words are " +
+			"wrong or in the wrong order")
+	}
+
+	return nil
+}
+
+// ChangePass re-enciphers the passed cipher seed with a new passphrase.
+// Unlike aezeed's Mnemonic.ChangePass, which re-enciphers with the all-zero
+// salt as the salt isn't part of the deciphered seed, the seed is re-created
+// with a fresh random salt, while keeping its version, entropy and birthday.
+func ChangePass(oldSeed *aezeed.CipherSeed,
+	newPass []byte) (aezeed.Mnemonic, error) {
+
+	newSeed, err := aezeed.New(
+		oldSeed.InternalVersion, &oldSeed.Entropy,
+		oldSeed.BirthdayTime(),
+	)
+	if err != nil {
+		return aezeed.Mnemonic{}, err
+	}
+
+	return newSeed.ToMnemonic(newPass)
+}
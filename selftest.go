@@ -0,0 +1,115 @@
+package aezeedcheck
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// selfTestVector is a fixed seed, along with everything that's expected to
+// be derived from it. Its entropy is the seed of the first BIP0032 test
+// vector, so its master fingerprint can be checked against the BIP as well.
+var selfTestVector = struct {
+	mnemonic    string
+	passphrase  string
+	entropy     string
+	birthday    uint16
+	fingerprint string
+	nodePubKey  string
+	addrs       map[string]string
+}{
+	mnemonic: "above eager access floor volume soccer wave track reduce " +
+		"melt smile sick gate vicious capital clog office brand " +
+		"outer can hazard melody pilot civil",
+	passphrase:  "aezeedcheck",
+	entropy:     "000102030405060708090a0b0c0d0e0f",
+	birthday:    4016,
+	fingerprint: "3442193e",
+	nodePubKey: "0282faf17eeae0aa1dbdbe49c4fcbdb6738595ead9afdd7f8fb4a" +
+		"22c6c44d2849b",
+	addrs: map[string]string{
+		"p2wkh":  "bc1qpux3z758ulsxg69eptaakukraanqwtdxe5yy4c",
+		"np2wkh": "35KsULTNUcaFcJC3aKBnP38ZZW2Yu36khW",
+		"p2tr": "bc1pqqeyhah6g75dwr942xv40h255q4nshqw4k8ylyhe7plej2eg" +
+			"3mnqz9w4np",
+		"p2pkh": "1NQpH6Nf8QtR2HphLRcvuVqfhXBXsiWn8r",
+	},
+}
+
+// SelfTest runs the built-in test vector through the whole recovery, from
+// deciphering its mnemonic to deriving the node key and the first mainnet
+// address of each type, and checks that the results match the expected ones.
+// This catches any change to the derivation, e.g. due to an updated
+// dependency. If anything doesn't match, the returned error lists every
+// mismatch.
+func SelfTest() error {
+	v := selfTestVector
+
+	var m aezeed.Mnemonic
+	words := strings.Fields(v.mnemonic)
+	if len(words) != len(m) {
+		return fmt.Errorf("self-test mnemonic has %v words", len(words))
+	}
+	copy(m[:], words)
+
+	var mismatches []string
+	check := func(what, got, expected string) {
+		if got != expected {
+			mismatches = append(mismatches, fmt.Sprintf("%v: got "+
+				"%v, expected %v", what, got, expected))
+		}
+	}
+
+	cipherSeed, err := m.ToCipherSeed([]byte(v.passphrase))
+	if err != nil {
+		return fmt.Errorf("unable to decipher self-test seed: %w", err)
+	}
+	check("entropy", hex.EncodeToString(cipherSeed.Entropy[:]), v.entropy)
+	check(
+		"birthday", fmt.Sprint(cipherSeed.Birthday),
+		fmt.Sprint(v.birthday),
+	)
+	ZeroCipherSeed(cipherSeed)
+
+	cfg := DefaultConfig()
+	cfg.Mnemonic = m
+	cfg.Passphrase = []byte(v.passphrase)
+	res, err := recoverSeed(&cfg)
+	if err != nil {
+		return fmt.Errorf("unable to recover self-test seed: %w", err)
+	}
+	defer res.zero()
+
+	check(
+		"master fingerprint", hex.EncodeToString(res.fingerprint[:]),
+		v.fingerprint,
+	)
+	check(
+		"node pubkey",
+		hex.EncodeToString(res.nodePub.SerializeCompressed()),
+		v.nodePubKey,
+	)
+
+	found := make(map[string]bool)
+	for _, a := range res.addrs {
+		found[a.addrType.name] = true
+		what := fmt.Sprintf("%v address", a.addrType.name)
+		check(what, a.addr.EncodeAddress(), v.addrs[a.addrType.name])
+	}
+	for _, t := range addrTypes(&cfg) {
+		if !found[t.name] {
+			mismatches = append(mismatches, fmt.Sprintf("%v "+
+				"address: missing", t.name))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return errors.New("self-test failed:\n  " +
+			strings.Join(mismatches, "\n  "))
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// selfTestConfig returns the default config with the mnemonic and passphrase
+// of the self-test vector.
+func selfTestConfig(t testing.TB) Config {
+	t.Helper()
+
+	words := strings.Fields(selfTestVector.mnemonic)
+	if len(words) != aezeed.NummnemonicWords {
+		t.Fatalf("self-test mnemonic has %v words", len(words))
+	}
+
+	cfg := DefaultConfig()
+	copy(cfg.Mnemonic[:], words)
+	cfg.Passphrase = []byte(selfTestVector.passphrase)
+
+	return cfg
+}
+
+// TestSelfTest asserts that the built-in self-test passes.
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunSelfTestVector asserts that a recovery of the self-test vector prints
+// its fingerprint, node key and the first address of each type.
+func TestRunSelfTestVector(t *testing.T) {
+	var out bytes.Buffer
+	if err := Run(selfTestConfig(t), &out); err != nil {
+		t.Fatalf("unable to run recovery: %v", err)
+	}
+
+	expected := []string{
+		"Master fingerprint: " + selfTestVector.fingerprint + "\n",
+		"Node pub key:  " + selfTestVector.nodePubKey + " ",
+	}
+	for _, name := range []string{"p2wkh", "np2wkh", "p2tr", "p2pkh"} {
+		expected = append(expected, fmt.Sprintf("%v address #0: %v ",
+			name, selfTestVector.addrs[name]))
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected output to contain %q, instead got:"+
+				"\n%v", line, out.String())
+		}
+	}
+}
+
+// TestRunSelfTestVectorWrongPass asserts that the self-test vector doesn't
+// decipher with the wrong passphrase.
+func TestRunSelfTestVectorWrongPass(t *testing.T) {
+	cfg := selfTestConfig(t)
+	cfg.Passphrase = []byte("wrong")
+
+	var out bytes.Buffer
+	err := Run(cfg, &out)
+	if !errors.Is(err, aezeed.ErrInvalidPass) {
+		t.Fatalf("expected %v, instead got %v", aezeed.ErrInvalidPass,
+			err)
+	}
+}
@@ -0,0 +1,106 @@
+package aezeedcheck
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+const (
+	// shachainHeight is the number of bits of a shachain index, which
+	// bounds the number of per-commitment secrets of a channel.
+	shachainHeight = 48
+
+	// MaxCommitHeight is the highest commitment height that a
+	// per-commitment secret can be derived for.
+	MaxCommitHeight = 1<<shachainHeight - 1
+)
+
+// DeriveRevocationRoot derives the root of the shachain that lnd derives the
+// per-commitment secrets of a channel from, using the key at the passed index
+// of the revocation root key family as the root, like lnd v0.7 does. Each
+// channel uses the next index of the family.
+func DeriveRevocationRoot(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	index uint32) ([32]byte, error) {
+
+	var root [32]byte
+
+	if index >= hdkeychain.HardenedKeyStart {
+		return root, fmt.Errorf("key index must be below %v",
+			hdkeychain.HardenedKeyStart)
+	}
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return root, err
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return root, fmt.Errorf("unable to make HD priv root: %w", err)
+	}
+	defer rootKey.Zero()
+
+	revocationKey, err := DeriveKeyLocator(
+		rootKey, params.CoinType, keychain.KeyLocator{
+			Family: keychain.KeyFamilyRevocationRoot,
+			Index:  index,
+		},
+	)
+	if err != nil {
+		return root, fmt.Errorf("unable to derive revocation root "+
+			"key: %w", err)
+	}
+	defer revocationKey.Zero()
+
+	privKey, err := revocationKey.ECPrivKey()
+	if err != nil {
+		return root, err
+	}
+	defer ZeroPrivKey(privKey)
+
+	serialized := privKey.Serialize()
+	copy(root[:], serialized)
+	ZeroBytes(serialized)
+
+	return root, nil
+}
+
+// PerCommitmentSecret derives the per-commitment secret of the commitment at
+// the passed height from a shachain root, following BOLT #3. The first
+// commitment of a channel is at height 0, which maps to the highest shachain
+// index.
+func PerCommitmentSecret(root [32]byte, height uint64) ([32]byte, error) {
+	if height > MaxCommitHeight {
+		return [32]byte{}, fmt.Errorf("commitment height must be at "+
+			"most %v", uint64(MaxCommitHeight))
+	}
+
+	// For each bit that's set in the index, starting from the most
+	// significant one, we flip the same bit of the secret and hash it.
+	index := uint64(MaxCommitHeight) - height
+	secret := root
+	for bit := shachainHeight - 1; bit >= 0; bit-- {
+		if index>>uint(bit)&1 == 0 {
+			continue
+		}
+
+		secret[bit/8] ^= 1 << uint(bit%8)
+		secret = sha256.Sum256(secret[:])
+	}
+
+	return secret, nil
+}
+
+// PerCommitmentPoint returns the per-commitment point of a per-commitment
+// secret, which is the public key of the secret.
+func PerCommitmentPoint(secret [32]byte) *btcec.PublicKey {
+	_, point := btcec.PrivKeyFromBytes(btcec.S256(), secret[:])
+	return point
+}
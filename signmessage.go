@@ -0,0 +1,57 @@
+package aezeedcheck
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// lndMessagePrefix is the prefix lnd prepends to every message before it's
+// signed with the node key, so a signature can never be mistaken for one
+// over a transaction.
+const lndMessagePrefix = "Lightning Signed Message:"
+
+// lndMessageDigest returns the digest lnd signs for the passed message, which
+// is the double sha256 of the prefixed message.
+func lndMessageDigest(msg []byte) []byte {
+	prefixed := append([]byte(lndMessagePrefix), msg...)
+
+	return chainhash.DoubleHashB(prefixed)
+}
+
+// SignNodeMessage signs the passed message with the node private key, and
+// returns the zbase32-encoded recoverable signature, exactly like lncli
+// signmessage does.
+func SignNodeMessage(nodeKey *btcec.PrivateKey, msg []byte) (string, error) {
+	sig, err := btcec.SignCompact(
+		btcec.S256(), nodeKey, lndMessageDigest(msg), true,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return zbase32Encode(sig), nil
+}
+
+// RecoverNodeMessageKey recovers the node key that produced the passed
+// zbase32-encoded signature over the given message. Just like lnd, a
+// signature is considered valid if a key can be recovered from it, so it's up
+// to the caller to check that the key belongs to the expected node.
+func RecoverNodeMessageKey(msg []byte, sig string) (*btcec.PublicKey,
+	error) {
+
+	rawSig, err := zbase32Decode(sig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode signature: %w", err)
+	}
+
+	pubKey, _, err := btcec.RecoverCompact(
+		btcec.S256(), rawSig, lndMessageDigest(msg),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return pubKey, nil
+}
@@ -0,0 +1,79 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+const (
+	// serializedKeyLen is the length of a serialized extended key, not
+	// including the trailing 4-byte checksum.
+	serializedKeyLen = 78
+
+	// checksumLen is the length of the checksum appended to a serialized
+	// extended key before base58 encoding.
+	checksumLen = 4
+)
+
+// slip132Version holds the SLIP-0132 version bytes used for the account
+// extended public keys of a key scope.
+type slip132Version struct {
+	// mainnet is the version used on mainnet.
+	mainnet [4]byte
+
+	// testnet is the version used on all test networks.
+	testnet [4]byte
+}
+
+// slip132PubVersions maps the purpose of each key scope to the SLIP-0132
+// version bytes of its account extended public keys. Scopes that aren't
+// present use the standard xpub/tpub version bytes.
+var slip132PubVersions = map[uint32]slip132Version{
+	// zpub/vpub.
+	waddrmgr.KeyScopeBIP0084.Purpose: {
+		mainnet: [4]byte{0x04, 0xb2, 0x47, 0x46},
+		testnet: [4]byte{0x04, 0x5f, 0x1c, 0xf6},
+	},
+
+	// ypub/upub.
+	waddrmgr.KeyScopeBIP0049Plus.Purpose: {
+		mainnet: [4]byte{0x04, 0x9d, 0x7c, 0xb2},
+		testnet: [4]byte{0x04, 0x4a, 0x52, 0x62},
+	},
+}
+
+// Slip132Encode re-serializes the passed account extended public key using
+// the SLIP-0132 version bytes of the key scope with the given purpose, e.g.
+// as a zpub for BIP0084.
+func Slip132Encode(xpub string, purpose uint32,
+	params *chaincfg.Params) (string, error) {
+
+	version, ok := slip132PubVersions[purpose]
+	if !ok {
+		return xpub, nil
+	}
+
+	decoded := base58.Decode(xpub)
+	if len(decoded) != serializedKeyLen+checksumLen {
+		return "", fmt.Errorf("invalid extended key length: %v",
+			len(decoded))
+	}
+
+	// We'll swap out the first 4 bytes of the serialized key with the new
+	// version, then recompute the checksum over the result.
+	payload := decoded[:serializedKeyLen]
+	mainnetID := chaincfg.MainNetParams.HDPublicKeyID
+	if bytes.Equal(params.HDPublicKeyID[:], mainnetID[:]) {
+		copy(payload[:4], version.mainnet[:])
+	} else {
+		copy(payload[:4], version.testnet[:])
+	}
+	checksum := chainhash.DoubleHashB(payload)[:checksumLen]
+
+	return base58.Encode(append(payload, checksum...)), nil
+}
@@ -0,0 +1,619 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/golangcrypto/pbkdf2"
+)
+
+const (
+	// slip39RadixBits is the number of bits each word of a SLIP-0039 share
+	// encodes.
+	slip39RadixBits = 10
+
+	// slip39HeaderWords is the number of words at the start of a share
+	// that encode its identifier, iteration exponent and group and member
+	// parameters.
+	slip39HeaderWords = 4
+
+	// slip39ChecksumWords is the number of words at the end of a share
+	// that encode its RS1024 checksum.
+	slip39ChecksumWords = 3
+
+	// slip39MinSecretSize is the minimum size of a master secret in
+	// bytes.
+	slip39MinSecretSize = 16
+
+	// SLIP39MaxShares is the maximum number of shares of a group, as the
+	// index and threshold of a member are encoded in four bits.
+	SLIP39MaxShares = 16
+
+	// slip39IterationExp is the iteration exponent of newly split shares,
+	// which doubles the number of PBKDF2 iterations for each step.
+	slip39IterationExp = 1
+
+	// slip39DigestSize is the size of the digest that's stored alongside
+	// the shared secret, so that a wrong combination of shares is
+	// detected.
+	slip39DigestSize = 4
+
+	// slip39DigestIndex and slip39SecretIndex are the x coordinates at
+	// which the digest and the shared secret are evaluated.
+	slip39DigestIndex = 254
+	slip39SecretIndex = 255
+
+	// slip39BaseIterations is the total number of PBKDF2 iterations of the
+	// Feistel network at an iteration exponent of zero, which are spread
+	// evenly over its rounds.
+	slip39BaseIterations = 10000
+
+	// slip39Rounds is the number of rounds of the Feistel network that
+	// encrypts the master secret.
+	slip39Rounds = 4
+)
+
+var (
+	// slip39Customization is the customization string of the checksum and
+	// of the encryption salt of shares that aren't extendable.
+	slip39Customization = []byte("shamir")
+
+	// slip39CustomizationExt is the customization string of the checksum
+	// of extendable shares.
+	slip39CustomizationExt = []byte("shamir_extendable")
+
+	// rs1024Generator holds the coefficients of the generator of the
+	// RS1024 code that the checksum of a share is computed with.
+	rs1024Generator = [...]uint32{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+)
+
+// gf256Exp and gf256Log are the exponent and logarithm tables of GF(256) with
+// the Rijndael polynomial, in which the shares are interpolated.
+var gf256Exp, gf256Log [256]byte
+
+func init() {
+	poly := 1
+	for i := 0; i < 255; i++ {
+		gf256Exp[i] = byte(poly)
+		gf256Log[poly] = byte(i)
+
+		// Multiply by the generator x + 1.
+		poly = poly<<1 ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+}
+
+// slip39Share is a single decoded SLIP-0039 mnemonic share.
+type slip39Share struct {
+	identifier      uint16
+	extendable      bool
+	iterationExp    uint8
+	groupIndex      uint8
+	groupThreshold  uint8
+	groupCount      uint8
+	memberIndex     uint8
+	memberThreshold uint8
+	value           []byte
+}
+
+// rs1024Polymod computes the RS1024 checksum polynomial of the passed values.
+func rs1024Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+		for i, gen := range rs1024Generator {
+			if b>>uint(i)&1 == 1 {
+				chk ^= gen
+			}
+		}
+	}
+
+	return chk
+}
+
+// customizationValues returns the customization string of the checksum of a
+// share, as the values it's fed into the checksum with.
+func customizationValues(extendable bool) []int {
+	customization := slip39Customization
+	if extendable {
+		customization = slip39CustomizationExt
+	}
+
+	values := make([]int, len(customization))
+	for i, c := range customization {
+		values[i] = int(c)
+	}
+
+	return values
+}
+
+// parseSLIP39Share decodes the passed SLIP-0039 mnemonic share and verifies
+// its checksum.
+func parseSLIP39Share(mnemonic string) (*slip39Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	minWords := slip39HeaderWords + slip39ChecksumWords +
+		(slip39MinSecretSize*8+slip39RadixBits-1)/slip39RadixBits
+	if len(words) < minWords {
+		return nil, fmt.Errorf("expected at least %v words, instead "+
+			"got %v", minWords, len(words))
+	}
+
+	indices := make([]int, len(words))
+	for i, word := range words {
+		index, ok := slip39WordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("word %v (%q) isn't part of "+
+				"the SLIP-0039 word list", i+1, word)
+		}
+		indices[i] = index
+	}
+
+	// The value is padded to a multiple of a word with at most 8 zero
+	// bits, so any more than that means the share has the wrong length.
+	valueWords := len(words) - slip39HeaderWords - slip39ChecksumWords
+	paddingBits := slip39RadixBits * valueWords % 16
+	if paddingBits > 8 {
+		return nil, fmt.Errorf("invalid share length of %v words",
+			len(words))
+	}
+
+	share := &slip39Share{
+		identifier:      uint16(indices[0]<<5 | indices[1]>>5),
+		extendable:      indices[1]>>4&1 == 1,
+		iterationExp:    uint8(indices[1] & 0xf),
+		groupIndex:      uint8(indices[2] >> 6),
+		groupThreshold:  uint8(indices[2]>>2&0xf) + 1,
+		groupCount:      uint8(indices[2]&0x3<<2|indices[3]>>8) + 1,
+		memberIndex:     uint8(indices[3] >> 4 & 0xf),
+		memberThreshold: uint8(indices[3]&0xf) + 1,
+	}
+
+	values := append(customizationValues(share.extendable), indices...)
+	if rs1024Polymod(values) != 1 {
+		return nil, errors.New("checksum mismatch, one or more words " +
+			"are wrong or in the wrong order")
+	}
+
+	if share.groupCount < share.groupThreshold {
+		return nil, errors.New("the group threshold of the share " +
+			"exceeds its group count")
+	}
+
+	// We'll unpack the 10 bits encoded by each word of the value, of
+	// which the leading padding bits must all be zero.
+	valueIndices := indices[slip39HeaderWords : len(indices)-
+		slip39ChecksumWords]
+	share.value = make([]byte, (valueWords*slip39RadixBits-paddingBits)/8)
+	bitPos := -paddingBits
+	for _, index := range valueIndices {
+		for i := slip39RadixBits - 1; i >= 0; i-- {
+			bit := index >> uint(i) & 1
+			switch {
+			case bitPos < 0 && bit == 1:
+				return nil, errors.New("invalid padding of " +
+					"the share value")
+
+			case bitPos >= 0 && bit == 1:
+				share.value[bitPos/8] |= 0x80 >> uint(bitPos%8)
+			}
+			bitPos++
+		}
+	}
+
+	return share, nil
+}
+
+// sharePoint is a single point of a shared secret, with the x coordinate being
+// the index of its share.
+type sharePoint struct {
+	x     byte
+	value []byte
+}
+
+// interpolate evaluates the polynomial through the passed points at x, byte
+// by byte, using Lagrange interpolation in GF(256).
+func interpolate(points []sharePoint, x byte) []byte {
+	for _, point := range points {
+		if point.x == x {
+			return append([]byte(nil), point.value...)
+		}
+	}
+
+	// The basis polynomial of each point is the product of (x - x_j) /
+	// (x_i - x_j) over all other points, which we'll compute in the log
+	// domain. Subtraction is the same as addition in GF(256), so it's a
+	// simple XOR.
+	var logProd int
+	for _, point := range points {
+		logProd += int(gf256Log[point.x^x])
+	}
+
+	result := make([]byte, len(points[0].value))
+	for i, point := range points {
+		logBasis := logProd - int(gf256Log[point.x^x])
+		for j, other := range points {
+			if i != j {
+				logBasis -= int(gf256Log[point.x^other.x])
+			}
+		}
+		logBasis = (logBasis%255 + 255) % 255
+
+		for k, v := range point.value {
+			if v == 0 {
+				continue
+			}
+			logTerm := (int(gf256Log[v]) + logBasis) % 255
+			result[k] ^= gf256Exp[logTerm]
+		}
+	}
+
+	return result
+}
+
+// slip39Digest returns the digest of the passed shared secret, keyed by the
+// given random bytes.
+func slip39Digest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+
+	return mac.Sum(nil)[:slip39DigestSize]
+}
+
+// recoverSecret recovers the secret shared by the passed points, which must
+// reach the given threshold, and verifies it against its digest.
+func recoverSecret(threshold uint8, points []sharePoint) ([]byte, error) {
+	// A threshold of one means every share holds the secret itself.
+	if threshold == 1 {
+		return append([]byte(nil), points[0].value...), nil
+	}
+
+	secret := interpolate(points, slip39SecretIndex)
+	digestShare := interpolate(points, slip39DigestIndex)
+	defer ZeroBytes(digestShare)
+
+	digest := slip39Digest(digestShare[slip39DigestSize:], secret)
+	if !hmac.Equal(digest, digestShare[:slip39DigestSize]) {
+		ZeroBytes(secret)
+		return nil, errors.New("digest mismatch, the shares don't " +
+			"belong to the same secret")
+	}
+
+	return secret, nil
+}
+
+// slip39Salt returns the salt of the Feistel network of the passed shares.
+func slip39Salt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+
+	return append(
+		append([]byte(nil), slip39Customization...),
+		byte(identifier>>8), byte(identifier),
+	)
+}
+
+// slip39Crypt runs the Feistel network that encrypts and decrypts the master
+// secret, with its rounds in the passed order.
+func slip39Crypt(input, passphrase []byte, iterationExp uint8,
+	salt []byte, rounds []byte) []byte {
+
+	half := len(input) / 2
+	l := append([]byte(nil), input[:half]...)
+	r := append([]byte(nil), input[half:]...)
+	iterations := (slip39BaseIterations << iterationExp) / slip39Rounds
+
+	for _, round := range rounds {
+		f := pbkdf2.Key(
+			append([]byte{round}, passphrase...),
+			append(append([]byte(nil), salt...), r...),
+			iterations, len(r), sha256.New,
+		)
+		for i := range l {
+			l[i] ^= f[i]
+		}
+		ZeroBytes(f)
+		l, r = r, l
+	}
+
+	return append(r, l...)
+}
+
+// CombineSLIP39Shares recovers the master secret from the passed SLIP-0039
+// mnemonic shares, decrypting it with the given passphrase. The shares must
+// reach the threshold of enough groups to reach the group threshold.
+//
+// NOTE: SLIP-0039 has no way of telling a wrong passphrase apart from the
+// right one, so any passphrase results in a master secret.
+func CombineSLIP39Shares(mnemonics []string,
+	passphrase []byte) ([]byte, error) {
+
+	if len(mnemonics) == 0 {
+		return nil, errors.New("no shares given")
+	}
+
+	var (
+		first  *slip39Share
+		groups = make(map[uint8][]*slip39Share)
+	)
+	for i, mnemonic := range mnemonics {
+		share, err := parseSLIP39Share(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid share %v: %w", i+1, err)
+		}
+		defer ZeroBytes(share.value)
+
+		if first == nil {
+			first = share
+		}
+		if share.identifier != first.identifier ||
+			share.extendable != first.extendable ||
+			share.iterationExp != first.iterationExp ||
+			share.groupThreshold != first.groupThreshold ||
+			share.groupCount != first.groupCount ||
+			len(share.value) != len(first.value) {
+
+			return nil, fmt.Errorf("share %v doesn't belong to "+
+				"the same set as share 1", i+1)
+		}
+
+		group := groups[share.groupIndex]
+		for _, other := range group {
+			if other.memberThreshold != share.memberThreshold {
+				return nil, fmt.Errorf("share %v has a "+
+					"different member threshold than "+
+					"the other shares of group %v", i+1,
+					share.groupIndex+1)
+			}
+			if other.memberIndex != share.memberIndex {
+				continue
+			}
+			if !bytes.Equal(other.value, share.value) {
+				return nil, fmt.Errorf("share %v conflicts "+
+					"with another share of group %v", i+1,
+					share.groupIndex+1)
+			}
+
+			// The same share was given twice, so we'll ignore
+			// the duplicate.
+			share = nil
+			break
+		}
+		if share != nil {
+			groups[share.groupIndex] = append(group, share)
+		}
+	}
+
+	// Groups that don't reach their member threshold are of no use, but
+	// we'll only fail if not enough of the others remain.
+	var (
+		groupPoints []sharePoint
+		incomplete  []string
+	)
+	for groupIndex, group := range groups {
+		threshold := group[0].memberThreshold
+		if len(group) < int(threshold) {
+			incomplete = append(incomplete, fmt.Sprintf("group %v "+
+				"has %v of %v shares", groupIndex+1, len(group),
+				threshold))
+			continue
+		}
+
+		points := make([]sharePoint, len(group))
+		for i, share := range group {
+			points[i] = sharePoint{
+				x:     share.memberIndex,
+				value: share.value,
+			}
+		}
+		groupSecret, err := recoverSecret(threshold, points)
+		if err != nil {
+			return nil, fmt.Errorf("unable to recover group %v: %w",
+				groupIndex+1, err)
+		}
+		defer ZeroBytes(groupSecret)
+
+		groupPoints = append(groupPoints, sharePoint{
+			x:     groupIndex,
+			value: groupSecret,
+		})
+	}
+	if len(groupPoints) < int(first.groupThreshold) {
+		msg := fmt.Sprintf("not enough shares, %v of %v groups are "+
+			"complete", len(groupPoints), first.groupThreshold)
+		if len(incomplete) > 0 {
+			msg += ": " + strings.Join(incomplete, ", ")
+		}
+		return nil, errors.New(msg)
+	}
+
+	encrypted, err := recoverSecret(first.groupThreshold, groupPoints)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recover master secret: %w",
+			err)
+	}
+	defer ZeroBytes(encrypted)
+
+	return slip39Crypt(
+		encrypted, passphrase, first.iterationExp,
+		slip39Salt(first.identifier, first.extendable),
+		[]byte{3, 2, 1, 0},
+	), nil
+}
+
+// encode returns the mnemonic of the share, including its checksum.
+func (s *slip39Share) encode() string {
+	valueWords := (len(s.value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	indices := make([]int, slip39HeaderWords, slip39HeaderWords+
+		valueWords+slip39ChecksumWords)
+
+	var ext int
+	if s.extendable {
+		ext = 1
+	}
+	indices[0] = int(s.identifier >> 5)
+	indices[1] = int(s.identifier&0x1f)<<5 | ext<<4 | int(s.iterationExp)
+	indices[2] = int(s.groupIndex)<<6 | int(s.groupThreshold-1)<<2 |
+		int(s.groupCount-1)>>2
+	indices[3] = int(s.groupCount-1)&0x3<<8 | int(s.memberIndex)<<4 |
+		int(s.memberThreshold-1)
+
+	// The value is left-padded with zero bits to a multiple of a word.
+	bitPos := len(s.value)*8 - valueWords*slip39RadixBits
+	for i := 0; i < valueWords; i++ {
+		var index int
+		for j := 0; j < slip39RadixBits; j++ {
+			index <<= 1
+			if bitPos >= 0 {
+				b := s.value[bitPos/8] >> uint(7-bitPos%8)
+				index |= int(b & 1)
+			}
+			bitPos++
+		}
+		indices = append(indices, index)
+	}
+
+	values := append(customizationValues(s.extendable), indices...)
+	values = append(values, make([]int, slip39ChecksumWords)...)
+	checksum := rs1024Polymod(values) ^ 1
+	for i := slip39ChecksumWords - 1; i >= 0; i-- {
+		indices = append(
+			indices, int(checksum>>uint(slip39RadixBits*i)&0x3ff),
+		)
+	}
+
+	words := make([]string, len(indices))
+	for i, index := range indices {
+		words[i] = SLIP39WordList[index]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// splitSecret splits the passed secret into numShares points, any threshold
+// of which recover it, along with a digest of the secret.
+func splitSecret(threshold, numShares int, secret []byte) ([]sharePoint,
+	error) {
+
+	// A threshold of one means every share holds the secret itself.
+	points := make([]sharePoint, 0, numShares)
+	if threshold == 1 {
+		for i := 0; i < numShares; i++ {
+			points = append(points, sharePoint{
+				x:     byte(i),
+				value: append([]byte(nil), secret...),
+			})
+		}
+		return points, nil
+	}
+
+	// The first threshold-2 shares are random, which together with the
+	// digest and the secret itself determine the polynomial that the
+	// remaining shares are evaluated on.
+	for i := 0; i < threshold-2; i++ {
+		value := make([]byte, len(secret))
+		if _, err := rand.Read(value); err != nil {
+			return nil, err
+		}
+		points = append(points, sharePoint{x: byte(i), value: value})
+	}
+
+	randomPart := make([]byte, len(secret)-slip39DigestSize)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+	digestShare := append(slip39Digest(randomPart, secret), randomPart...)
+	defer ZeroBytes(digestShare)
+	ZeroBytes(randomPart)
+
+	basePoints := append(
+		append([]sharePoint(nil), points...),
+		sharePoint{x: slip39DigestIndex, value: digestShare},
+		sharePoint{x: slip39SecretIndex, value: secret},
+	)
+	for i := threshold - 2; i < numShares; i++ {
+		points = append(points, sharePoint{
+			x:     byte(i),
+			value: interpolate(basePoints, byte(i)),
+		})
+	}
+
+	return points, nil
+}
+
+// SplitSLIP39Secret encrypts the passed master secret with the given
+// passphrase, and splits it into numShares SLIP-0039 mnemonic shares of a
+// single group, any threshold of which recover the secret. The shares are
+// extendable, so more shares of the same set can be created later on.
+func SplitSLIP39Secret(secret []byte, threshold, numShares int,
+	passphrase []byte) ([]string, error) {
+
+	if len(secret) < slip39MinSecretSize || len(secret)%2 != 0 {
+		return nil, fmt.Errorf("the master secret must be an even "+
+			"number of bytes, and at least %v bytes long",
+			slip39MinSecretSize)
+	}
+	if threshold < 1 || threshold > numShares ||
+		numShares > SLIP39MaxShares {
+
+		return nil, fmt.Errorf("expected 1 <= threshold <= shares <= "+
+			"%v, instead got %v-of-%v", SLIP39MaxShares, threshold,
+			numShares)
+	}
+
+	// Every share of a 1-of-N set would be the same, which only gives a
+	// false sense of having distinct shares.
+	if threshold == 1 && numShares > 1 {
+		return nil, errors.New("a threshold of 1 requires a single " +
+			"share")
+	}
+
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(id[:]) & 0x7fff
+
+	encrypted := slip39Crypt(
+		secret, passphrase, slip39IterationExp,
+		slip39Salt(identifier, true), []byte{0, 1, 2, 3},
+	)
+	defer ZeroBytes(encrypted)
+
+	// With a single group, the group level share is the encrypted secret
+	// itself, which is then split among the members of the group.
+	points, err := splitSecret(threshold, numShares, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("unable to split secret: %w", err)
+	}
+
+	mnemonics := make([]string, len(points))
+	for i, point := range points {
+		share := &slip39Share{
+			identifier:      identifier,
+			extendable:      true,
+			iterationExp:    slip39IterationExp,
+			groupThreshold:  1,
+			groupCount:      1,
+			memberIndex:     point.x,
+			memberThreshold: uint8(threshold),
+			value:           point.value,
+		}
+		mnemonics[i] = share.encode()
+		ZeroBytes(point.value)
+	}
+
+	return mnemonics, nil
+}
@@ -0,0 +1,124 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"testing"
+)
+
+// slip39Vectors are test vectors of the SLIP-0039 specification, all of which
+// use the passphrase TREZOR.
+var slip39Vectors = []struct {
+	name      string
+	mnemonics []string
+	secret    string
+}{
+	{
+		name: "valid mnemonic without sharing (128 bits)",
+		mnemonics: []string{
+			"duckling enlarge academic academic agency result " +
+				"length solution fridge kidney coal piece " +
+				"deal husband erode duke ajar critical " +
+				"decision keyboard",
+		},
+		secret: "bb54aac4b89dc868ba37d9cc21b2cece",
+	},
+	{
+		name: "mnemonic with invalid checksum (128 bits)",
+		mnemonics: []string{
+			"duckling enlarge academic academic agency result " +
+				"length solution fridge kidney coal piece " +
+				"deal husband erode duke ajar critical " +
+				"decision kidney",
+		},
+	},
+	{
+		name: "basic sharing 2-of-3 (128 bits)",
+		mnemonics: []string{
+			"shadow pistol academic always adequate wildlife " +
+				"fancy gross oasis cylinder mustang wrist " +
+				"rescue view short owner flip making coding " +
+				"armed",
+			"shadow pistol academic acid actress prayer class " +
+				"unknown daughter sweater depict flip twice " +
+				"unkind craft early superior advocate guest " +
+				"smoking",
+		},
+		secret: "b43ceb7e57a0ea8766221624d01b0864",
+	},
+	{
+		name: "basic sharing 2-of-3, insufficient shares",
+		mnemonics: []string{
+			"shadow pistol academic always adequate wildlife " +
+				"fancy gross oasis cylinder mustang wrist " +
+				"rescue view short owner flip making coding " +
+				"armed",
+		},
+	},
+	{
+		name: "valid extendable mnemonic without sharing (128 bits)",
+		mnemonics: []string{
+			"testify swimming academic academic column loyalty " +
+				"smear include exotic bedroom exotic wrist " +
+				"lobe cover grief golden smart junior " +
+				"estimate learn",
+		},
+		secret: "1679b4516e0ee5954351d288a838f45e",
+	},
+}
+
+// TestCombineSLIP39Shares asserts that CombineSLIP39Shares recovers the master
+// secret of the valid SLIP-0039 test vectors, and rejects the invalid ones.
+func TestCombineSLIP39Shares(t *testing.T) {
+	for _, vector := range slip39Vectors {
+		vector := vector
+		t.Run(vector.name, func(t *testing.T) {
+			secret, err := CombineSLIP39Shares(
+				vector.mnemonics, []byte("TREZOR"),
+			)
+			if vector.secret == "" {
+				if err == nil {
+					t.Fatalf("expected an error, instead "+
+						"got secret %x", secret)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to combine shares: %v", err)
+			}
+
+			expected := decodeHex(t, vector.secret)
+			if !bytes.Equal(secret, expected) {
+				t.Fatalf("expected secret %x, instead got %x",
+					expected, secret)
+			}
+		})
+	}
+}
+
+// TestSplitSLIP39Secret asserts that any threshold of the shares made by
+// SplitSLIP39Secret recovers the secret, while fewer shares don't.
+func TestSplitSLIP39Secret(t *testing.T) {
+	secret := decodeHex(t, "bb54aac4b89dc868ba37d9cc21b2cece")
+	pass := []byte("TREZOR")
+
+	shares, err := SplitSLIP39Secret(secret, 3, 5, pass)
+	if err != nil {
+		t.Fatalf("unable to split secret: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, instead got %v", len(shares))
+	}
+
+	combined, err := CombineSLIP39Shares(shares[2:], pass)
+	if err != nil {
+		t.Fatalf("unable to combine shares: %v", err)
+	}
+	if !bytes.Equal(combined, secret) {
+		t.Fatalf("expected secret %x, instead got %x", secret,
+			combined)
+	}
+
+	if _, err := CombineSLIP39Shares(shares[:2], pass); err == nil {
+		t.Fatal("expected two of three shares to be rejected")
+	}
+}
@@ -0,0 +1,92 @@
+package aezeedcheck
+
+import (
+	"sort"
+)
+
+const (
+	// maxSuggestDistance is the maximum edit distance between a mistyped
+	// word and the words of the word list that are suggested in its
+	// place.
+	maxSuggestDistance = 2
+
+	// maxSuggestions is the maximum number of words suggested in place of
+	// a mistyped word.
+	maxSuggestions = 3
+)
+
+// SuggestWords returns up to maxSuggestions words of the aezeed word list that
+// are within maxSuggestDistance edits of the passed word, closest first.
+func SuggestWords(word string) []string {
+	type candidate struct {
+		word     string
+		distance int
+	}
+
+	var candidates []candidate
+	for _, w := range WordList {
+		distance := levenshtein(word, w)
+		if distance <= maxSuggestDistance {
+			candidates = append(candidates, candidate{w, distance})
+		}
+	}
+
+	// Ties are kept in the order of the word list, which is sorted
+	// alphabetically.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	if len(candidates) > maxSuggestions {
+		candidates = candidates[:maxSuggestions]
+	}
+
+	suggestions := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.word)
+	}
+
+	return suggestions
+}
+
+// levenshtein computes the Levenshtein distance between the passed strings,
+// which is the minimum number of single character insertions, deletions and
+// substitutions needed to turn one into the other.
+func levenshtein(a, b string) int {
+	// We only keep the previous and current row of the distance matrix
+	// around, as each row only depends on the one before it.
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min3(
+				prev[j]+1, cur[j-1]+1, prev[j-1]+cost,
+			)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// min3 returns the smallest of the three passed integers.
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
@@ -0,0 +1,290 @@
+package aezeedcheck
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+const (
+	// BIP0086Purpose is the purpose of the BIP0086 key scope, used to
+	// derive single key p2tr outputs. The version of btcwallet we depend
+	// on predates taproot, so it doesn't define this scope yet.
+	BIP0086Purpose = 86
+
+	// taprootWitnessVersion is the segwit version of p2tr outputs.
+	taprootWitnessVersion = 1
+
+	// bech32mConst is the constant that the bech32m checksum of segwit
+	// v1+ addresses is XOR'd with, as defined in BIP0350.
+	bech32mConst = 0x2bc830a3
+
+	// bech32Charset is the set of characters used to encode the data part
+	// of a bech32(m) string.
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// taggedHash computes the BIP0340 tagged hash of the given message:
+// sha256(sha256(tag) || sha256(tag) || msg).
+func taggedHash(tag string, msg ...[]byte) [sha256.Size]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+
+	var hash [sha256.Size]byte
+	copy(hash[:], h.Sum(nil))
+
+	return hash
+}
+
+// taprootOutputKey computes the BIP0086 output key of the passed internal
+// key, by applying the BIP0341 taproot tweak with an empty merkle root. The
+// returned key is the 32-byte x-only serialization of the output key.
+func taprootOutputKey(internalKey *btcec.PublicKey) []byte {
+	curve := btcec.S256()
+
+	// BIP0340 keys are x-only, and implicitly have an even y coordinate.
+	// If our key has an odd y coordinate, then we'll negate it so the
+	// tweak is applied to the key the x-only serialization refers to.
+	x, y := internalKey.X, internalKey.Y
+	if y.Bit(0) == 1 {
+		y = new(big.Int).Sub(curve.P, y)
+	}
+
+	// With no script tree, the tweak commits only to the internal key:
+	// t = H_TapTweak(x(P)), Q = P + t*G.
+	xOnly := serializeXOnly(x)
+	tweak := taggedHash("TapTweak", xOnly)
+	tx, ty := curve.ScalarBaseMult(tweak[:])
+	qx, _ := curve.Add(x, y, tx, ty)
+
+	return serializeXOnly(qx)
+}
+
+// serializeXOnly serializes the passed x coordinate as a 32-byte big-endian
+// integer, as used by BIP0340 x-only public keys.
+func serializeXOnly(x *big.Int) []byte {
+	var b [32]byte
+	xBytes := x.Bytes()
+	copy(b[32-len(xBytes):], xBytes)
+
+	return b[:]
+}
+
+// KeyToP2trAddr returns the BIP0086 p2tr address of the passed key, encoded
+// for the given network.
+func KeyToP2trAddr(key *btcec.PublicKey,
+	params *chaincfg.Params) (btcutil.Address, error) {
+
+	return newAddressTaproot(taprootOutputKey(key), params)
+}
+
+// PayToAddrScript creates a new script to pay a transaction output to the
+// passed address. Unlike txscript.PayToAddrScript, p2tr addresses are
+// supported as well.
+func PayToAddrScript(addr btcutil.Address) ([]byte, error) {
+	taprootAddr, ok := addr.(*addressTaproot)
+	if !ok {
+		return txscript.PayToAddrScript(addr)
+	}
+
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).
+		AddData(taprootAddr.ScriptAddress()).
+		Script()
+}
+
+// DecodeAddress decodes the passed address for the given network. Unlike
+// btcutil.DecodeAddress, p2tr addresses are supported as well.
+func DecodeAddress(addr string,
+	params *chaincfg.Params) (btcutil.Address, error) {
+
+	hrp := strings.ToLower(params.Bech32HRPSegwit)
+	prefix := hrp + "1p"
+	if !strings.HasPrefix(strings.ToLower(addr), prefix) {
+		return btcutil.DecodeAddress(addr, params)
+	}
+
+	data, err := bech32mDecode(hrp, addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || data[0] != taprootWitnessVersion {
+		return nil, fmt.Errorf("expected witness version %v",
+			taprootWitnessVersion)
+	}
+
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAddressTaproot(program, params)
+}
+
+// addressTaproot is a pay-to-taproot (segwit v1) address. The version of
+// btcutil we depend on doesn't know about taproot and bech32m yet, so we
+// implement the btcutil.Address interface ourselves.
+type addressTaproot struct {
+	hrp            string
+	witnessProgram [32]byte
+}
+
+// A compile time check to ensure addressTaproot satisfies the btcutil.Address
+// interface.
+var _ btcutil.Address = (*addressTaproot)(nil)
+
+// newAddressTaproot returns a new p2tr address paying to the given 32-byte
+// x-only output key.
+func newAddressTaproot(outputKey []byte,
+	params *chaincfg.Params) (*addressTaproot, error) {
+
+	if len(outputKey) != 32 {
+		return nil, fmt.Errorf("witness program must be 32 bytes for "+
+			"p2tr, instead got %v", len(outputKey))
+	}
+
+	addr := &addressTaproot{
+		hrp: strings.ToLower(params.Bech32HRPSegwit),
+	}
+	copy(addr.witnessProgram[:], outputKey)
+
+	return addr, nil
+}
+
+// EncodeAddress returns the bech32m string encoding of the address.
+//
+// NOTE: This method is part of the btcutil.Address interface.
+func (a *addressTaproot) EncodeAddress() string {
+	// The data part consists of the witness version, followed by the
+	// witness program regrouped into 5-bit words.
+	converted, err := bech32.ConvertBits(a.witnessProgram[:], 8, 5, true)
+	if err != nil {
+		return ""
+	}
+	data := append([]byte{taprootWitnessVersion}, converted...)
+
+	return bech32mEncode(a.hrp, data)
+}
+
+// ScriptAddress returns the witness program of the address, which is the
+// x-only output key.
+//
+// NOTE: This method is part of the btcutil.Address interface.
+func (a *addressTaproot) ScriptAddress() []byte {
+	return a.witnessProgram[:]
+}
+
+// IsForNet returns whether or not the address is associated with the passed
+// network.
+//
+// NOTE: This method is part of the btcutil.Address interface.
+func (a *addressTaproot) IsForNet(net *chaincfg.Params) bool {
+	return a.hrp == strings.ToLower(net.Bech32HRPSegwit)
+}
+
+// String returns a human-readable string of the address.
+//
+// NOTE: This method is part of the btcutil.Address interface.
+func (a *addressTaproot) String() string {
+	return a.EncodeAddress()
+}
+
+// bech32mEncode encodes the given 5-bit grouped data under the passed HRP,
+// appending a BIP0350 bech32m checksum.
+func bech32mEncode(hrp string, data []byte) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	values = append(values, make([]byte, 6)...)
+	polymod := bech32Polymod(values) ^ bech32mConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, b := range data {
+		sb.WriteByte(bech32Charset[b])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+
+	return sb.String()
+}
+
+// bech32mDecode decodes the bech32m string under the passed HRP, and returns
+// its 5-bit grouped data without the checksum. Just like in bech32, the string
+// must not mix upper and lower case.
+func bech32mDecode(hrp, s string) ([]byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return nil, fmt.Errorf("mixed case in %q", s)
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 0 || s[:sep] != hrp || len(s)-sep-1 < 6 {
+		return nil, fmt.Errorf("invalid bech32m string %q", s)
+	}
+
+	data := make([]byte, 0, len(s)-sep-1)
+	for _, c := range s[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return nil, fmt.Errorf("invalid bech32m character %q",
+				c)
+		}
+		data = append(data, byte(v))
+	}
+
+	values := append(bech32HrpExpand(hrp), data...)
+	if bech32Polymod(values) != bech32mConst {
+		return nil, fmt.Errorf("invalid bech32m checksum in %q", s)
+	}
+
+	return data[:len(data)-6], nil
+}
+
+// bech32HrpExpand expands the HRP into the values used for computing the
+// bech32(m) checksum.
+func bech32HrpExpand(hrp string) []byte {
+	values := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	return values
+}
+
+// bech32Polymod computes the BCH checksum polynomial over the passed values.
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{
+		0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3,
+	}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
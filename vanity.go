@@ -0,0 +1,184 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+const (
+	// vanityBatchSize is the number of indexes that are searched
+	// concurrently before checking for a match. This bounds the wasted
+	// work once a match is found, while still guaranteeing that the
+	// lowest matching index is returned.
+	vanityBatchSize = 1000
+)
+
+// FindVanityAddr searches the external branch of the first account of the
+// given bech32 address type for the address with the lowest index that
+// contains substr, ignoring case. At most maxIndexes indexes are searched,
+// spread across a worker per CPU.
+func FindVanityAddr(cfg *Config, cipherSeed *aezeed.CipherSeed,
+	addrTypeName, substr string, maxIndexes uint32) (KeyPath,
+	btcutil.Address, error) {
+
+	params, err := cfg.NetParams()
+	if err != nil {
+		return KeyPath{}, nil, err
+	}
+
+	var (
+		t     addrType
+		found bool
+	)
+	for _, candidate := range addrTypes(cfg) {
+		if candidate.name == addrTypeName {
+			t, found = candidate, true
+		}
+	}
+	if !found || (t.name != "p2wkh" && t.name != "p2tr") {
+		return KeyPath{}, nil, fmt.Errorf("unsupported vanity "+
+			"address type %q, expected one of: p2wkh, p2tr",
+			addrTypeName)
+	}
+
+	if maxIndexes > hdkeychain.HardenedKeyStart {
+		return KeyPath{}, nil, fmt.Errorf("at most %v indexes can be "+
+			"searched", hdkeychain.HardenedKeyStart)
+	}
+
+	// A substring with characters that can't appear in an address of the
+	// type would only exhaust the search, so we'll reject it up front.
+	substr = strings.ToLower(substr)
+	hrp := strings.ToLower(params.Bech32HRPSegwit)
+	for _, c := range substr {
+		if !strings.ContainsRune(bech32Charset+hrp+"1", c) {
+			return KeyPath{}, nil, fmt.Errorf("%q can't appear in "+
+				"a bech32 address", c)
+		}
+	}
+
+	rootKey, err := hdkeychain.NewMaster(
+		cipherSeed.Entropy[:], params.Params,
+	)
+	if err != nil {
+		return KeyPath{}, nil, fmt.Errorf("unable to make HD priv "+
+			"root: %w", err)
+	}
+	keyCache := newAccountKeyCache(rootKey, params.CoinType)
+	defer keyCache.zero()
+
+	accountKey, err := keyCache.accountKey(t.purpose, 0)
+	if err != nil {
+		return KeyPath{}, nil, fmt.Errorf("unable to derive %v "+
+			"account key: %w", t.name, err)
+	}
+
+	// Only public keys are needed for the addresses, so the workers
+	// derive them from the neutered branch key, which is safe for
+	// concurrent use. As it shares its public key with the private branch
+	// key, the latter is only zeroed once the search is done.
+	branchKey, err := accountKey.Child(ExternalBranch)
+	if err != nil {
+		return KeyPath{}, nil, fmt.Errorf("unable to derive %v "+
+			"branch key: %w", t.name, err)
+	}
+	defer branchKey.Zero()
+
+	branchPub, err := branchKey.Neuter()
+	if err != nil {
+		return KeyPath{}, nil, fmt.Errorf("unable to neuter %v "+
+			"branch key: %w", t.name, err)
+	}
+
+	for start := uint32(0); start < maxIndexes; start += vanityBatchSize {
+		end := start + vanityBatchSize
+		if end > maxIndexes || end < start {
+			end = maxIndexes
+		}
+
+		index, addr, err := searchVanityBatch(
+			branchPub, t, params, substr, start, end,
+		)
+		if err != nil {
+			return KeyPath{}, nil, err
+		}
+		if addr != nil {
+			return KeyPath{
+				Purpose:  t.purpose,
+				CoinType: params.CoinType,
+				Branch:   ExternalBranch,
+				Index:    index,
+			}, addr, nil
+		}
+	}
+
+	return KeyPath{}, nil, fmt.Errorf("none of the first %v %v "+
+		"addresses contain %q", maxIndexes, t.name, substr)
+}
+
+// searchVanityBatch searches the indexes in [start, end) of the passed public
+// branch key for an address that contains substr, which must be lowercase. The
+// matching address with the lowest index is returned, or a nil address if
+// there's none.
+func searchVanityBatch(branchPub *hdkeychain.ExtendedKey, t addrType,
+	params *NetParams, substr string, start,
+	end uint32) (uint32, btcutil.Address, error) {
+
+	var (
+		mtx       sync.Mutex
+		bestIndex uint32
+		bestAddr  btcutil.Address
+		firstErr  error
+	)
+	checkIndex := func(i uint32) {
+		addr, _, err := deriveAddr(
+			branchPub, t, i, params.Params, false,
+		)
+
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		switch {
+		case err != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to derive %v "+
+					"addr at index %v: %w", t.name, i, err)
+			}
+
+		case !strings.Contains(addr.EncodeAddress(), substr):
+
+		case bestAddr == nil || i < bestIndex:
+			bestIndex, bestAddr = i, addr
+		}
+	}
+
+	indexes := make(chan uint32)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				checkIndex(i)
+			}
+		}()
+	}
+	for i := start; i < end; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, nil, firstErr
+	}
+
+	return bestIndex, bestAddr, nil
+}
@@ -0,0 +1,204 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+const (
+	// accountDepth is the depth of an account extended key, following the
+	// purpose and coin type levels.
+	accountDepth = 3
+)
+
+// WatchAccount is an account extended public key that addresses can be
+// derived from without the seed.
+type WatchAccount struct {
+	// Key is the parsed account extended public key.
+	Key *hdkeychain.ExtendedKey
+
+	// Purpose is the BIP0043 purpose of the key scope that the version
+	// bytes of the key signal.
+	Purpose uint32
+
+	// Mainnet is true if the version bytes of the key are those used on
+	// mainnet, rather than on the test networks.
+	Mainnet bool
+
+	// Account is the account number of the key, without the hardened
+	// offset.
+	Account uint32
+}
+
+// ParseWatchAccount parses the passed account extended public key, and
+// detects its key scope from its SLIP-0132 version bytes. As the standard
+// xpub/tpub version bytes don't signal a scope, they're assumed to belong to
+// the BIP0044 scope.
+func ParseWatchAccount(xpub string) (*WatchAccount, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsPrivate() {
+		return nil, errors.New("expected an extended public key, not " +
+			"an extended private key")
+	}
+	if key.Depth() != accountDepth {
+		return nil, fmt.Errorf("expected an account extended key at "+
+			"depth %v, instead got depth %v", accountDepth,
+			key.Depth())
+	}
+
+	// The key was already validated above, so we only need to pick out
+	// the version and child number of the serialization.
+	decoded := base58.Decode(xpub)
+	var version [4]byte
+	copy(version[:], decoded[:4])
+	childNum := binary.BigEndian.Uint32(decoded[9:13])
+	if childNum < hdkeychain.HardenedKeyStart {
+		return nil, errors.New("expected a hardened account key")
+	}
+
+	account := &WatchAccount{
+		Key:     key,
+		Account: childNum - hdkeychain.HardenedKeyStart,
+	}
+
+	mainnetID := chaincfg.MainNetParams.HDPublicKeyID
+	testnetID := chaincfg.TestNet3Params.HDPublicKeyID
+	switch {
+	case bytes.Equal(version[:], mainnetID[:]):
+		account.Purpose = waddrmgr.KeyScopeBIP0044.Purpose
+		account.Mainnet = true
+		return account, nil
+
+	case bytes.Equal(version[:], testnetID[:]):
+		account.Purpose = waddrmgr.KeyScopeBIP0044.Purpose
+		return account, nil
+	}
+
+	for purpose, slip132Version := range slip132PubVersions {
+		switch version {
+		case slip132Version.mainnet:
+			account.Purpose = purpose
+			account.Mainnet = true
+			return account, nil
+
+		case slip132Version.testnet:
+			account.Purpose = purpose
+			return account, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown extended public key version %x",
+		version[:])
+}
+
+// validateWatchOnly checks that the config doesn't request anything that
+// requires the seed, as only the configured account xpub is available.
+func (c *Config) validateWatchOnly() error {
+	if c.Xprv || c.WIF || c.KeyLocatorPriv || c.ShowEntropy || c.BIP39 {
+		return errors.New("private key material can't be derived " +
+			"from an extended public key")
+	}
+
+	if len(c.AddrTypes) > 0 {
+		return errors.New("the address type of an extended public " +
+			"key can only be overridden, not selected")
+	}
+	if len(c.Accounts) > 0 {
+		return errors.New("the account of an extended public key " +
+			"can't be selected")
+	}
+
+	if c.KeyFamilies || c.KeyLocator != nil || c.TowerSessions > 0 ||
+		c.SCB != nil || c.SCBDir != "" ||
+		c.Xpub || c.Descriptors || c.Format == FormatBitcoind ||
+		c.Format == FormatElectrum || c.Format == FormatSparrow ||
+		c.Format == FormatColdcard {
+
+		return errors.New("only addresses can be derived from an " +
+			"extended public key")
+	}
+
+	return nil
+}
+
+// recoverWatchOnly derives the configured addresses from the account xpub of
+// the passed config, without the seed.
+func recoverWatchOnly(cfg *Config) (*recoveryResult, error) {
+	params, err := cfg.NetParams()
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := ParseWatchAccount(cfg.WatchXpub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended public key: %w", err)
+	}
+
+	// Other coins may share the version bytes of Bitcoin's mainnet, so we
+	// compare those rather than the network itself.
+	mainnetID := chaincfg.MainNetParams.HDPublicKeyID
+	isMainnet := params.HDPublicKeyID == mainnetID
+	if account.Mainnet != isMainnet {
+		return nil, fmt.Errorf("extended public key doesn't belong "+
+			"to %v", params.Name)
+	}
+
+	// Unless the address type was given explicitly, we'll derive the
+	// type that belongs to the detected scope.
+	var (
+		t     addrType
+		found bool
+	)
+	for _, candidate := range addrTypes(cfg) {
+		if cfg.WatchAddrType != "" {
+			found = candidate.name == cfg.WatchAddrType
+		} else {
+			found = candidate.purpose == account.Purpose
+		}
+		if found {
+			t = candidate
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown address type %q",
+			cfg.WatchAddrType)
+	}
+
+	res := &recoveryResult{
+		watchAccount: &accountXpub{
+			addrType: t,
+			path: KeyPath{
+				Purpose:  t.purpose,
+				CoinType: params.CoinType,
+				Account:  account.Account,
+			},
+			xpub: cfg.WatchXpub,
+		},
+		numAddrs: cfg.Count,
+	}
+	if cfg.BulkImport {
+		res.numAddrs += cfg.GapLimit
+	}
+
+	res.addrs, err = deriveAddrs(
+		cfg, params, []addrType{t},
+		[]*hdkeychain.ExtendedKey{account.Key}, account.Account,
+		res.numAddrs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,204 @@
+package aezeedcheck
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/golangcrypto/poly1305"
+)
+
+const (
+	// xchachaNonceSize is the size of the nonce used by
+	// XChaCha20-Poly1305.
+	xchachaNonceSize = 24
+
+	// poly1305TagSize is the size of the authentication tag appended to
+	// the ciphertext by ChaCha20-Poly1305.
+	poly1305TagSize = 16
+)
+
+// errOpen is returned when a ciphertext fails to authenticate, either because
+// the key is wrong or because the ciphertext was altered.
+var errOpen = errors.New("message authentication failed")
+
+// chachaConstants are the four constant words of the ChaCha20 state, forming
+// the string "expand 32-byte k".
+var chachaConstants = [4]uint32{
+	0x61707865, 0x3320646e, 0x79622d32, 0x6b206574,
+}
+
+// chachaQuarterRound applies the ChaCha quarter round to the passed words.
+func chachaQuarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d ^= a
+	d = d<<16 | d>>16
+	c += d
+	b ^= c
+	b = b<<12 | b>>20
+	a += b
+	d ^= a
+	d = d<<8 | d>>24
+	c += d
+	b ^= c
+	b = b<<7 | b>>25
+
+	return a, b, c, d
+}
+
+// chachaRounds applies the 20 rounds of ChaCha to the passed state, without
+// the final feed forward.
+func chachaRounds(s *[16]uint32) {
+	for i := 0; i < 10; i++ {
+		// Column rounds.
+		s[0], s[4], s[8], s[12] = chachaQuarterRound(
+			s[0], s[4], s[8], s[12],
+		)
+		s[1], s[5], s[9], s[13] = chachaQuarterRound(
+			s[1], s[5], s[9], s[13],
+		)
+		s[2], s[6], s[10], s[14] = chachaQuarterRound(
+			s[2], s[6], s[10], s[14],
+		)
+		s[3], s[7], s[11], s[15] = chachaQuarterRound(
+			s[3], s[7], s[11], s[15],
+		)
+
+		// Diagonal rounds.
+		s[0], s[5], s[10], s[15] = chachaQuarterRound(
+			s[0], s[5], s[10], s[15],
+		)
+		s[1], s[6], s[11], s[12] = chachaQuarterRound(
+			s[1], s[6], s[11], s[12],
+		)
+		s[2], s[7], s[8], s[13] = chachaQuarterRound(
+			s[2], s[7], s[8], s[13],
+		)
+		s[3], s[4], s[9], s[14] = chachaQuarterRound(
+			s[3], s[4], s[9], s[14],
+		)
+	}
+}
+
+// chachaState builds the initial ChaCha20 state from the passed key, and the
+// 16 bytes making up the counter and nonce.
+func chachaState(key *[32]byte, counterNonce []byte) [16]uint32 {
+	var s [16]uint32
+	copy(s[:4], chachaConstants[:])
+	for i := 0; i < 8; i++ {
+		s[4+i] = binary.LittleEndian.Uint32(key[i*4:])
+	}
+	for i := 0; i < 4; i++ {
+		s[12+i] = binary.LittleEndian.Uint32(counterNonce[i*4:])
+	}
+
+	return s
+}
+
+// hChaCha20 derives the XChaCha20 subkey from the passed key and the first 16
+// bytes of the extended nonce.
+func hChaCha20(key *[32]byte, nonce []byte) [32]byte {
+	s := chachaState(key, nonce[:16])
+	chachaRounds(&s)
+
+	var subKey [32]byte
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint32(subKey[i*4:], s[i])
+		binary.LittleEndian.PutUint32(subKey[16+i*4:], s[12+i])
+	}
+
+	return subKey
+}
+
+// chacha20XOR XORs the passed input with the ChaCha20 key stream of the given
+// key and 12-byte nonce, starting at the given block counter.
+func chacha20XOR(key *[32]byte, nonce []byte, counter uint32,
+	in []byte) []byte {
+
+	out := make([]byte, len(in))
+
+	var counterNonce [16]byte
+	copy(counterNonce[4:], nonce)
+
+	var block [64]byte
+	for pos := 0; pos < len(in); pos += len(block) {
+		binary.LittleEndian.PutUint32(counterNonce[:4], counter)
+		counter++
+
+		s := chachaState(key, counterNonce[:])
+		initial := s
+		chachaRounds(&s)
+		for i := range s {
+			binary.LittleEndian.PutUint32(
+				block[i*4:], s[i]+initial[i],
+			)
+		}
+
+		for i := 0; i < len(block) && pos+i < len(in); i++ {
+			out[pos+i] = in[pos+i] ^ block[i]
+		}
+	}
+
+	return out
+}
+
+// xchacha20Poly1305Open authenticates and decrypts the passed ciphertext with
+// XChaCha20-Poly1305, as defined by the IETF draft, and returns the
+// plaintext. The version of x/crypto we depend on predates the XChaCha20
+// construction, so we implement it on top of the poly1305 package ourselves.
+func xchacha20Poly1305Open(key *[32]byte, nonce, ciphertext,
+	ad []byte) ([]byte, error) {
+
+	if len(nonce) != xchachaNonceSize {
+		return nil, fmt.Errorf("nonce must be %v bytes, instead got "+
+			"%v", xchachaNonceSize, len(nonce))
+	}
+	if len(ciphertext) < poly1305TagSize {
+		return nil, errOpen
+	}
+
+	// The extended nonce is used by first deriving a subkey from its first
+	// 16 bytes, then using that subkey with the remaining 8 bytes as a
+	// regular ChaCha20-Poly1305 nonce.
+	subKey := hChaCha20(key, nonce)
+	var chachaNonce [12]byte
+	copy(chachaNonce[4:], nonce[16:])
+
+	// The one-time poly1305 key is the start of the first key stream
+	// block, while the message itself is encrypted from the second block.
+	var polyKey [32]byte
+	copy(polyKey[:], chacha20XOR(&subKey, chachaNonce[:], 0, polyKey[:]))
+
+	tagStart := len(ciphertext) - poly1305TagSize
+	ct, tag := ciphertext[:tagStart], ciphertext[tagStart:]
+
+	var expectedTag [poly1305TagSize]byte
+	poly1305.Sum(&expectedTag, poly1305Input(ad, ct), &polyKey)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag) != 1 {
+		return nil, errOpen
+	}
+
+	return chacha20XOR(&subKey, chachaNonce[:], 1, ct), nil
+}
+
+// poly1305Input builds the message that's authenticated by ChaCha20-Poly1305:
+// the additional data and ciphertext each padded to 16 bytes, followed by
+// their little-endian lengths.
+func poly1305Input(ad, ct []byte) []byte {
+	pad := func(n int) int {
+		return (16 - n%16) % 16
+	}
+
+	msg := make([]byte, 0, len(ad)+pad(len(ad))+len(ct)+pad(len(ct))+16)
+	msg = append(msg, ad...)
+	msg = append(msg, make([]byte, pad(len(ad)))...)
+	msg = append(msg, ct...)
+	msg = append(msg, make([]byte, pad(len(ct)))...)
+
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(ad)))
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(ct)))
+
+	return append(msg, lengths[:]...)
+}
@@ -0,0 +1,86 @@
+package aezeedcheck
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// decodeHex decodes the passed hex string, failing the test if it's invalid.
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+
+	return b
+}
+
+// TestHChaCha20 asserts that the subkey derived by hChaCha20 matches the test
+// vector of section 2.2.1 of draft-irtf-cfrg-xchacha.
+func TestHChaCha20(t *testing.T) {
+	var key [32]byte
+	copy(key[:], decodeHex(t, "000102030405060708090a0b0c0d0e0f"+
+		"101112131415161718191a1b1c1d1e1f"))
+	nonce := decodeHex(t, "000000090000004a0000000031415927")
+
+	expected := decodeHex(t, "82413b4227b27bfed30e42508a877d73"+
+		"a0f9e4d58a74a853c12ec41326d3ecdc")
+
+	subKey := hChaCha20(&key, nonce)
+	if !bytes.Equal(subKey[:], expected) {
+		t.Fatalf("expected subkey %x, instead got %x", expected,
+			subKey)
+	}
+}
+
+// TestXChaCha20Poly1305Open asserts that xchacha20Poly1305Open decrypts the
+// AEAD test vector of appendix A.3.1 of draft-irtf-cfrg-xchacha, and that it
+// rejects the ciphertext once any part of it is altered.
+func TestXChaCha20Poly1305Open(t *testing.T) {
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If " +
+		"I could offer you only one tip for the future, sunscreen " +
+		"would be it.")
+	ad := decodeHex(t, "50515253c0c1c2c3c4c5c6c7")
+	var key [32]byte
+	copy(key[:], decodeHex(t, "808182838485868788898a8b8c8d8e8f"+
+		"909192939495969798999a9b9c9d9e9f"))
+	nonce := decodeHex(t, "404142434445464748494a4b4c4d4e4f"+
+		"5051525354555657")
+	ciphertext := decodeHex(t, "bd6d179d3e83d43b9576579493c0e939"+
+		"572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f"+
+		"4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945"+
+		"b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c"+
+		"97637da9768812f615c68b13b52e"+
+		"c0875924c1c7987947deafd8780acf49")
+
+	got, err := xchacha20Poly1305Open(&key, nonce, ciphertext, ad)
+	if err != nil {
+		t.Fatalf("unable to open ciphertext: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("expected plaintext %q, instead got %q", plaintext,
+			got)
+	}
+
+	for i := range ciphertext {
+		altered := append([]byte(nil), ciphertext...)
+		altered[i] ^= 0x01
+
+		_, err := xchacha20Poly1305Open(&key, nonce, altered, ad)
+		if err != errOpen {
+			t.Fatalf("expected %v with byte %v altered, instead "+
+				"got %v", errOpen, i, err)
+		}
+	}
+
+	alteredAD := append([]byte(nil), ad...)
+	alteredAD[0] ^= 0x01
+	_, err = xchacha20Poly1305Open(&key, nonce, ciphertext, alteredAD)
+	if err != errOpen {
+		t.Fatalf("expected %v with altered additional data, instead "+
+			"got %v", errOpen, err)
+	}
+}
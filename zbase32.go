@@ -0,0 +1,60 @@
+package aezeedcheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// zbase32Alphabet is the alphabet of the human-oriented z-base-32 encoding,
+// used by lnd to encode message signatures.
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// zbase32Encode encodes the passed bytes with z-base-32, five bits per
+// character starting with the most significant bit. Any bits left over in
+// the final character are zero, and no padding is added.
+func zbase32Encode(data []byte) string {
+	var (
+		sb      strings.Builder
+		buf     uint32
+		numBits uint
+	)
+	for _, b := range data {
+		buf = buf<<8 | uint32(b)
+		numBits += 8
+		for numBits >= 5 {
+			numBits -= 5
+			sb.WriteByte(zbase32Alphabet[(buf>>numBits)&31])
+		}
+	}
+	if numBits > 0 {
+		sb.WriteByte(zbase32Alphabet[(buf<<(5-numBits))&31])
+	}
+
+	return sb.String()
+}
+
+// zbase32Decode decodes the passed z-base-32 string, dropping any trailing
+// bits that don't make up a full byte.
+func zbase32Decode(s string) ([]byte, error) {
+	var (
+		data    []byte
+		buf     uint32
+		numBits uint
+	)
+	for i := 0; i < len(s); i++ {
+		val := strings.IndexByte(zbase32Alphabet, s[i])
+		if val == -1 {
+			return nil, fmt.Errorf("invalid z-base-32 character "+
+				"%q", s[i])
+		}
+
+		buf = buf<<5 | uint32(val)
+		numBits += 5
+		if numBits >= 8 {
+			numBits -= 8
+			data = append(data, byte(buf>>numBits))
+		}
+	}
+
+	return data, nil
+}
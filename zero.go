@@ -0,0 +1,31 @@
+package aezeedcheck
+
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+// ZeroBytes overwrites the passed slice with zeroes, so secrets don't linger
+// in memory once they're no longer needed.
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// ZeroCipherSeed overwrites the entropy of the passed cipher seed with zeroes,
+// as the aezeed package doesn't offer a way to clear it.
+func ZeroCipherSeed(c *aezeed.CipherSeed) {
+	ZeroBytes(c.Entropy[:])
+}
+
+// ZeroPrivKey overwrites the scalar of the passed private key with zeroes.
+// Resetting the big.Int alone would leave its words in memory, so we'll clear
+// them first.
+func ZeroPrivKey(k *btcec.PrivateKey) {
+	words := k.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	k.D.SetInt64(0)
+}